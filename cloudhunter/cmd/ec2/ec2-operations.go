@@ -0,0 +1,469 @@
+package ec2
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/Kimi99/cloudhunter/internal/aws"
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+)
+
+var region string
+var profile string
+var allRegions bool
+var worldOpenOnly bool
+var showUserData bool
+var publicOwner string
+var sharedOnly bool
+var ctx = context.TODO()
+
+var EnumInstancesCmd = &cobra.Command{
+	Use:   "instances",
+	Short: "Retrieve EC2 instances with their instance profiles, public IPs and IMDS configuration",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Starting EC2 instance enumeration...")
+
+		forEachRegion(func(wrapper aws.Ec2Wrapper, scanRegion string) error {
+			instances, err := wrapper.DescribeInstancesWrapper(ctx)
+			if err != nil {
+				return err
+			}
+
+			for _, instance := range instances {
+				fmt.Printf("[+] Found instance!\n Instance ID: %s\n Name: %s\n State: %s\n Type: %s\n",
+					awssdk.ToString(instance.InstanceId), instanceName(instance.Tags), stateName(instance.State), instance.InstanceType)
+				fmt.Printf(" Private IP: %s\n Public IP: %s\n", awssdk.ToString(instance.PrivateIpAddress), awssdk.ToString(instance.PublicIpAddress))
+				if instance.IamInstanceProfile != nil {
+					fmt.Printf(" Instance profile: %s\n", awssdk.ToString(instance.IamInstanceProfile.Arn))
+				}
+				if instance.MetadataOptions != nil {
+					fmt.Printf(" IMDS: %s\n", imdsVersion(instance.MetadataOptions))
+				}
+				fmt.Printf(" VPC: %s\n Subnet: %s\n Key pair: %s\n\n", awssdk.ToString(instance.VpcId), awssdk.ToString(instance.SubnetId), awssdk.ToString(instance.KeyName))
+			}
+			return nil
+		})
+	},
+}
+
+var EnumSecurityGroupsCmd = &cobra.Command{
+	Use:   "security-groups",
+	Short: "Retrieve security groups and flag inbound rules open to the world",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Starting security group enumeration...")
+
+		forEachRegion(func(wrapper aws.Ec2Wrapper, scanRegion string) error {
+			groups, err := wrapper.DescribeSecurityGroupsWrapper(ctx)
+			if err != nil {
+				return err
+			}
+
+			for _, group := range groups {
+				var openRules []types.IpPermission
+				for _, permission := range group.IpPermissions {
+					if aws.IsOpenToWorld(permission) {
+						openRules = append(openRules, permission)
+					}
+				}
+
+				if worldOpenOnly && len(openRules) == 0 {
+					continue
+				}
+
+				fmt.Printf("[+] Found security group!\n Group ID: %s\n Group name: %s\n VPC: %s\n Description: %s\n",
+					awssdk.ToString(group.GroupId), awssdk.ToString(group.GroupName), awssdk.ToString(group.VpcId), awssdk.ToString(group.Description))
+				for _, permission := range group.IpPermissions {
+					marker := ""
+					if aws.IsOpenToWorld(permission) {
+						marker = " [OPEN TO WORLD]"
+					}
+					fmt.Printf(" Inbound: %s%s\n", formatPermission(permission), marker)
+				}
+				fmt.Println()
+			}
+			return nil
+		})
+	},
+}
+
+var EnumKeyPairsCmd = &cobra.Command{
+	Use:   "key-pairs",
+	Short: "Retrieve EC2 key pairs",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Retrieving key pairs...")
+
+		forEachRegion(func(wrapper aws.Ec2Wrapper, scanRegion string) error {
+			keyPairs, err := wrapper.DescribeKeyPairsWrapper(ctx)
+			if err != nil {
+				return err
+			}
+
+			for _, keyPair := range keyPairs {
+				fmt.Printf("[+] Found key pair!\n Key name: %s\n Key ID: %s\n Type: %s\n Fingerprint: %s\n Created date: %v\n\n",
+					awssdk.ToString(keyPair.KeyName), awssdk.ToString(keyPair.KeyPairId), keyPair.KeyType, awssdk.ToString(keyPair.KeyFingerprint), awssdk.ToTime(keyPair.CreateTime))
+			}
+			return nil
+		})
+	},
+}
+
+var EnumVpcsCmd = &cobra.Command{
+	Use:   "vpcs",
+	Short: "Retrieve VPCs and their subnets",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Retrieving VPCs and subnets...")
+
+		forEachRegion(func(wrapper aws.Ec2Wrapper, scanRegion string) error {
+			vpcs, err := wrapper.DescribeVpcsWrapper(ctx)
+			if err != nil {
+				return err
+			}
+
+			subnets, err := wrapper.DescribeSubnetsWrapper(ctx)
+			if err != nil {
+				return err
+			}
+
+			for _, vpc := range vpcs {
+				fmt.Printf("[+] Found VPC!\n VPC ID: %s\n Name: %s\n CIDR: %s\n Default: %t\n",
+					awssdk.ToString(vpc.VpcId), instanceName(vpc.Tags), awssdk.ToString(vpc.CidrBlock), awssdk.ToBool(vpc.IsDefault))
+				for _, subnet := range subnets {
+					if awssdk.ToString(subnet.VpcId) != awssdk.ToString(vpc.VpcId) {
+						continue
+					}
+					fmt.Printf(" Subnet: %s (%s, %s, public IP on launch: %t)\n",
+						awssdk.ToString(subnet.SubnetId), awssdk.ToString(subnet.CidrBlock), awssdk.ToString(subnet.AvailabilityZone), awssdk.ToBool(subnet.MapPublicIpOnLaunch))
+				}
+				fmt.Println()
+			}
+			return nil
+		})
+	},
+}
+
+var EnumNetworkInterfacesCmd = &cobra.Command{
+	Use:   "enis",
+	Short: "Retrieve elastic network interfaces",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Retrieving network interfaces...")
+
+		forEachRegion(func(wrapper aws.Ec2Wrapper, scanRegion string) error {
+			interfaces, err := wrapper.DescribeNetworkInterfacesWrapper(ctx)
+			if err != nil {
+				return err
+			}
+
+			for _, eni := range interfaces {
+				publicIp := ""
+				if eni.Association != nil {
+					publicIp = awssdk.ToString(eni.Association.PublicIp)
+				}
+
+				fmt.Printf("[+] Found network interface!\n Interface ID: %s\n Type: %s\n Description: %s\n Private IP: %s\n Public IP: %s\n VPC: %s\n Subnet: %s\n",
+					awssdk.ToString(eni.NetworkInterfaceId), eni.InterfaceType, awssdk.ToString(eni.Description), awssdk.ToString(eni.PrivateIpAddress), publicIp, awssdk.ToString(eni.VpcId), awssdk.ToString(eni.SubnetId))
+				if eni.Attachment != nil {
+					fmt.Printf(" Attached to: %s\n", awssdk.ToString(eni.Attachment.InstanceId))
+				}
+				fmt.Println()
+			}
+			return nil
+		})
+	},
+}
+
+var EnumUserDataCmd = &cobra.Command{
+	Use:   "user-data",
+	Short: "Extract user data of instances, launch template versions and launch configurations and scan it for secrets",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Retrieving user data...")
+
+		forEachRegion(func(wrapper aws.Ec2Wrapper, scanRegion string) error {
+			instances, err := wrapper.DescribeInstancesWrapper(ctx)
+			if err != nil {
+				return err
+			}
+
+			for _, instance := range instances {
+				userData, err := wrapper.GetInstanceUserDataWrapper(ctx, awssdk.ToString(instance.InstanceId))
+				if err != nil {
+					log.Printf("[!] Couldn't retrieve user data of instance %s: %v", awssdk.ToString(instance.InstanceId), err)
+					continue
+				}
+				reportUserData("Instance "+awssdk.ToString(instance.InstanceId), userData)
+			}
+
+			templates, err := wrapper.DescribeLaunchTemplatesWrapper(ctx)
+			if err != nil {
+				log.Printf("[!] Couldn't list launch templates: %v", err)
+			}
+			for _, template := range templates {
+				versions, err := wrapper.DescribeLaunchTemplateVersionsWrapper(ctx, awssdk.ToString(template.LaunchTemplateId))
+				if err != nil {
+					log.Printf("[!] Couldn't retrieve versions of launch template %s: %v", awssdk.ToString(template.LaunchTemplateName), err)
+					continue
+				}
+
+				for _, version := range versions {
+					if version.LaunchTemplateData == nil {
+						continue
+					}
+					source := fmt.Sprintf("Launch template %s (%s) version %d", awssdk.ToString(template.LaunchTemplateName), awssdk.ToString(template.LaunchTemplateId), awssdk.ToInt64(version.VersionNumber))
+					reportUserData(source, awssdk.ToString(version.LaunchTemplateData.UserData))
+				}
+			}
+
+			configurations, err := aws.InitializeAutoScalingWrapper(ctx, scanRegion, profile).DescribeLaunchConfigurationsWrapper(ctx)
+			if err != nil {
+				log.Printf("[!] Couldn't list launch configurations: %v", err)
+			}
+			for _, configuration := range configurations {
+				reportUserData("Launch configuration "+awssdk.ToString(configuration.LaunchConfigurationName), awssdk.ToString(configuration.UserData))
+			}
+
+			return nil
+		})
+	},
+}
+
+var EnumSnapshotsCmd = &cobra.Command{
+	Use:   "snapshots",
+	Short: "Retrieve EBS snapshots and flag public or cross-account sharing",
+	Long:  "Retrieve the account's EBS snapshots and inspect their createVolumePermission attribute. With --public-owner, search the public snapshots owned by the given account instead.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if publicOwner != "" {
+			fmt.Printf("[!] Searching public EBS snapshots owned by %s...\n", publicOwner)
+		} else {
+			fmt.Println("[!] Retrieving EBS snapshots...")
+		}
+
+		forEachRegion(func(wrapper aws.Ec2Wrapper, scanRegion string) error {
+			if publicOwner != "" {
+				snapshots, err := wrapper.DescribeSnapshotsWrapper(ctx, []string{publicOwner}, []string{aws.PublicGroup})
+				if err != nil {
+					return err
+				}
+				for _, snapshot := range snapshots {
+					printSnapshot(snapshot, shared.ResourceSharing{Public: true})
+				}
+				return nil
+			}
+
+			snapshots, err := wrapper.DescribeSnapshotsWrapper(ctx, []string{"self"}, nil)
+			if err != nil {
+				return err
+			}
+
+			for _, snapshot := range snapshots {
+				// Failures are printed as unknown sharing.
+				sharing, _ := wrapper.GetSnapshotSharingWrapper(ctx, awssdk.ToString(snapshot.SnapshotId))
+				if sharedOnly && !sharing.MayBeShared() {
+					continue
+				}
+				printSnapshot(snapshot, sharing)
+			}
+			return nil
+		})
+	},
+}
+
+var EnumImagesCmd = &cobra.Command{
+	Use:   "amis",
+	Short: "Retrieve AMIs and flag public or cross-account sharing",
+	Long:  "Retrieve the account's AMIs and inspect their launchPermission attribute. With --public-owner, search the public AMIs owned by the given account instead.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if publicOwner != "" {
+			fmt.Printf("[!] Searching public AMIs owned by %s...\n", publicOwner)
+		} else {
+			fmt.Println("[!] Retrieving AMIs...")
+		}
+
+		forEachRegion(func(wrapper aws.Ec2Wrapper, scanRegion string) error {
+			if publicOwner != "" {
+				images, err := wrapper.DescribeImagesWrapper(ctx, []string{publicOwner}, []string{aws.PublicGroup})
+				if err != nil {
+					return err
+				}
+				for _, image := range images {
+					printImage(image, shared.ResourceSharing{Public: true})
+				}
+				return nil
+			}
+
+			images, err := wrapper.DescribeImagesWrapper(ctx, []string{"self"}, nil)
+			if err != nil {
+				return err
+			}
+
+			for _, image := range images {
+				// Failures are printed as unknown sharing.
+				sharing, _ := wrapper.GetImageSharingWrapper(ctx, awssdk.ToString(image.ImageId))
+				if sharedOnly && !sharing.MayBeShared() {
+					continue
+				}
+				printImage(image, sharing)
+			}
+			return nil
+		})
+	},
+}
+
+// reportUserData decodes the user data and prints every secret found in it.
+func reportUserData(source string, encoded string) {
+	if encoded == "" {
+		return
+	}
+
+	userData, err := shared.DecodeUserData(encoded)
+	if err != nil {
+		log.Printf("[!] Couldn't decode user data of %s: %v", source, err)
+		return
+	}
+
+	secrets := shared.DetectSecrets(userData)
+	if len(secrets) == 0 && !showUserData {
+		return
+	}
+
+	fmt.Printf("[+] %s has user data with %d possible secrets\n", source, len(secrets))
+	for _, secret := range secrets {
+		fmt.Printf(" Line %d (%s): %s\n", secret.Line, secret.Rule, secret.Match)
+	}
+	if showUserData {
+		fmt.Printf(" User data:\n%s\n", userData)
+	}
+	fmt.Println()
+}
+
+// forEachRegion runs fn against a wrapper for every region selected by the --region and
+// --all-regions flags. Failures in one region are logged and don't stop the others.
+func forEachRegion(fn func(wrapper aws.Ec2Wrapper, scanRegion string) error) {
+	for _, scanRegion := range shared.RegionsToScan(region, allRegions) {
+		if allRegions {
+			fmt.Printf("[!] Region: %s\n", scanRegion)
+		}
+
+		wrapper := aws.InitializeEc2Wrapper(ctx, scanRegion, profile)
+		if err := fn(wrapper, scanRegion); err != nil {
+			if !allRegions {
+				shared.Fatal(err)
+			}
+			log.Printf("[!] Skipping region %s: %v", scanRegion, err)
+		}
+	}
+}
+
+func printSnapshot(snapshot types.Snapshot, sharing shared.ResourceSharing) {
+	fmt.Printf("[+] Found snapshot!\n Snapshot ID: %s\n Name: %s\n Owner: %s\n Volume: %s (%d GiB)\n Encrypted: %t\n Started: %v\n Description: %s\n Shared with: %s\n\n",
+		awssdk.ToString(snapshot.SnapshotId), instanceName(snapshot.Tags), awssdk.ToString(snapshot.OwnerId), awssdk.ToString(snapshot.VolumeId), awssdk.ToInt32(snapshot.VolumeSize),
+		awssdk.ToBool(snapshot.Encrypted), awssdk.ToTime(snapshot.StartTime), awssdk.ToString(snapshot.Description), sharing)
+}
+
+func printImage(image types.Image, sharing shared.ResourceSharing) {
+	fmt.Printf("[+] Found AMI!\n Image ID: %s\n Name: %s\n Owner: %s\n State: %s\n Created: %s\n Description: %s\n Shared with: %s\n",
+		awssdk.ToString(image.ImageId), awssdk.ToString(image.Name), awssdk.ToString(image.OwnerId), image.State, awssdk.ToString(image.CreationDate), awssdk.ToString(image.Description), sharing)
+	for _, mapping := range image.BlockDeviceMappings {
+		if mapping.Ebs != nil && mapping.Ebs.SnapshotId != nil {
+			fmt.Printf(" Snapshot: %s (%s, encrypted: %t)\n", awssdk.ToString(mapping.Ebs.SnapshotId), awssdk.ToString(mapping.DeviceName), awssdk.ToBool(mapping.Ebs.Encrypted))
+		}
+	}
+	fmt.Println()
+}
+
+func instanceName(tags []types.Tag) string {
+	for _, tag := range tags {
+		if awssdk.ToString(tag.Key) == "Name" {
+			return awssdk.ToString(tag.Value)
+		}
+	}
+
+	return ""
+}
+
+func stateName(state *types.InstanceState) string {
+	if state == nil {
+		return ""
+	}
+
+	return string(state.Name)
+}
+
+func imdsVersion(options *types.InstanceMetadataOptionsResponse) string {
+	if options.HttpEndpoint == types.InstanceMetadataEndpointStateDisabled {
+		return "disabled"
+	}
+	if options.HttpTokens == types.HttpTokensStateRequired {
+		return "IMDSv2 only"
+	}
+
+	return "IMDSv1 allowed"
+}
+
+func formatPermission(permission types.IpPermission) string {
+	protocol := awssdk.ToString(permission.IpProtocol)
+	ports := fmt.Sprintf("%d-%d", awssdk.ToInt32(permission.FromPort), awssdk.ToInt32(permission.ToPort))
+	if protocol == "-1" {
+		protocol, ports = "all", "all"
+	} else if awssdk.ToInt32(permission.FromPort) == awssdk.ToInt32(permission.ToPort) {
+		ports = fmt.Sprint(awssdk.ToInt32(permission.FromPort))
+	}
+
+	var sources []string
+	for _, ipRange := range permission.IpRanges {
+		sources = append(sources, awssdk.ToString(ipRange.CidrIp))
+	}
+	for _, ipRange := range permission.Ipv6Ranges {
+		sources = append(sources, awssdk.ToString(ipRange.CidrIpv6))
+	}
+	for _, pair := range permission.UserIdGroupPairs {
+		sources = append(sources, awssdk.ToString(pair.GroupId))
+	}
+	for _, prefixList := range permission.PrefixListIds {
+		sources = append(sources, awssdk.ToString(prefixList.PrefixListId))
+	}
+
+	return fmt.Sprintf("%s %s from %v", protocol, ports, sources)
+}
+
+func init() {
+	EnumInstancesCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumInstancesCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	EnumInstancesCmd.Flags().BoolVar(&allRegions, "all-regions", false, "Enumerate every region")
+
+	EnumSecurityGroupsCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumSecurityGroupsCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	EnumSecurityGroupsCmd.Flags().BoolVar(&allRegions, "all-regions", false, "Enumerate every region")
+	EnumSecurityGroupsCmd.Flags().BoolVar(&worldOpenOnly, "world-open-only", false, "Only show groups with inbound rules open to 0.0.0.0/0 or ::/0")
+
+	EnumKeyPairsCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumKeyPairsCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	EnumKeyPairsCmd.Flags().BoolVar(&allRegions, "all-regions", false, "Enumerate every region")
+
+	EnumVpcsCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumVpcsCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	EnumVpcsCmd.Flags().BoolVar(&allRegions, "all-regions", false, "Enumerate every region")
+
+	EnumNetworkInterfacesCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumNetworkInterfacesCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	EnumNetworkInterfacesCmd.Flags().BoolVar(&allRegions, "all-regions", false, "Enumerate every region")
+
+	EnumUserDataCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumUserDataCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	EnumUserDataCmd.Flags().BoolVar(&allRegions, "all-regions", false, "Enumerate every region")
+	EnumUserDataCmd.Flags().BoolVar(&showUserData, "show-user-data", false, "Print the decoded user data, even without detected secrets")
+
+	EnumSnapshotsCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumSnapshotsCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	EnumSnapshotsCmd.Flags().BoolVar(&allRegions, "all-regions", false, "Enumerate every region")
+	EnumSnapshotsCmd.Flags().StringVar(&publicOwner, "public-owner", "", "Search public snapshots owned by this account ID instead of the caller's snapshots")
+	EnumSnapshotsCmd.Flags().BoolVar(&sharedOnly, "shared-only", false, "Only show snapshots shared publicly or with other accounts, or whose sharing couldn't be read")
+
+	EnumImagesCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumImagesCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	EnumImagesCmd.Flags().BoolVar(&allRegions, "all-regions", false, "Enumerate every region")
+	EnumImagesCmd.Flags().StringVar(&publicOwner, "public-owner", "", "Search public AMIs owned by this account ID instead of the caller's AMIs")
+	EnumImagesCmd.Flags().BoolVar(&sharedOnly, "shared-only", false, "Only show AMIs shared publicly, with other accounts or organizations, or whose sharing couldn't be read")
+}
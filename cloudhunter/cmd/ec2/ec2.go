@@ -0,0 +1,19 @@
+package ec2
+
+import "github.com/spf13/cobra"
+
+var Ec2Cmd = &cobra.Command{
+	Use:   "ec2",
+	Short: "Interact with AWS EC2 service",
+}
+
+func init() {
+	Ec2Cmd.AddCommand(EnumInstancesCmd)
+	Ec2Cmd.AddCommand(EnumSecurityGroupsCmd)
+	Ec2Cmd.AddCommand(EnumKeyPairsCmd)
+	Ec2Cmd.AddCommand(EnumVpcsCmd)
+	Ec2Cmd.AddCommand(EnumNetworkInterfacesCmd)
+	Ec2Cmd.AddCommand(EnumUserDataCmd)
+	Ec2Cmd.AddCommand(EnumSnapshotsCmd)
+	Ec2Cmd.AddCommand(EnumImagesCmd)
+}
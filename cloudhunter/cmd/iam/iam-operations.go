@@ -3,7 +3,6 @@ package iam
 import (
 	"context"
 	"fmt"
-	"log"
 
 	"github.com/Kimi99/cloudhunter/internal/aws"
 	"github.com/Kimi99/cloudhunter/internal/shared"
@@ -28,7 +27,7 @@ var EnumUsersCmd = &cobra.Command{
 
 		users, err := wrapper.ListUsersWrapper(ctx)
 		if err != nil {
-			log.Fatal(err)
+			shared.Fatal(err)
 		}
 
 		fmt.Println("[+] Found following users:")
@@ -48,7 +47,7 @@ var EnumSpecificUserCmd = &cobra.Command{
 
 		user, err := wrapper.GetUserWrapper(ctx, userName)
 		if err != nil {
-			log.Fatal(err)
+			shared.Fatal(err)
 		}
 
 		fmt.Println("[+] Retrieved user info:")
@@ -66,7 +65,7 @@ var EnumAccessKeysCmd = &cobra.Command{
 
 		accessKeys, err := wrapper.ListAccessKeysWrapper(ctx)
 		if err != nil {
-			log.Fatal(err)
+			shared.Fatal(err)
 		}
 
 		for _, accessKey := range accessKeys {
@@ -85,7 +84,7 @@ var EnumUserPoliciesCmd = &cobra.Command{
 
 		userPolicies, err := wrapper.ListUserPoliciesWrapper(ctx, userName)
 		if err != nil {
-			log.Fatal(err)
+			shared.Fatal(err)
 		}
 
 		for _, userPolicy := range userPolicies {
@@ -104,7 +103,7 @@ var EnumUserPolicyDocumentCmd = &cobra.Command{
 
 		policy, err := wrapper.GetUserPolicyWrapper(ctx, userName, policyName)
 		if err != nil {
-			log.Fatal(err)
+			shared.Fatal(err)
 		}
 
 		fmt.Printf("[+] Found user policy document!\n %s", policy)
@@ -121,7 +120,7 @@ var EnumGroupsCmd = &cobra.Command{
 
 		groups, err := wrapper.ListGroupsWrapper(ctx)
 		if err != nil {
-			log.Fatal(err)
+			shared.Fatal(err)
 		}
 
 		for _, group := range groups {
@@ -140,7 +139,7 @@ var EnumGroupsForUserCmd = &cobra.Command{
 
 		groups, err := wrapper.ListGroupsForUserWrapper(ctx, userName)
 		if err != nil {
-			log.Fatal(err)
+			shared.Fatal(err)
 		}
 
 		for _, group := range groups {
@@ -159,7 +158,7 @@ var EnumSpecificGroupCmd = &cobra.Command{
 
 		group, err := wrapper.GetGroupWrapper(ctx, groupName)
 		if err != nil {
-			log.Fatal(err)
+			shared.Fatal(err)
 		}
 
 		fmt.Printf("[+] Retrieved information about group:\n Group ARN: %s\n Group name: %s\n", *group.Group.Arn, *group.Group.GroupName)
@@ -181,7 +180,7 @@ var EnumGroupPoliciesCmd = &cobra.Command{
 
 		policies, err := wrapper.ListGroupPoliciesWrapper(ctx, groupName)
 		if err != nil {
-			log.Fatal(err)
+			shared.Fatal(err)
 		}
 
 		fmt.Println("[+] Found following group policies:")
@@ -201,7 +200,7 @@ var EnumGroupPolicyDocumentCmd = &cobra.Command{
 
 		policyDocument, err := wrapper.GetGroupPolicyDocumentWrapper(ctx, groupName, policyName)
 		if err != nil {
-			log.Fatal(err)
+			shared.Fatal(err)
 		}
 
 		fmt.Printf("[+] Found policy document:\n%s", policyDocument)
@@ -218,7 +217,7 @@ var EnumRolesCmd = &cobra.Command{
 
 		roles, err := wrapper.ListRolesWrapper(ctx)
 		if err != nil {
-			log.Fatal(err)
+			shared.Fatal(err)
 		}
 
 		fmt.Println("[+] Found following roles:")
@@ -238,7 +237,7 @@ var EnumSpecificRoleCmd = &cobra.Command{
 
 		role, err := wrapper.GetRoleWrapper(ctx, roleName)
 		if err != nil {
-			log.Fatal(err)
+			shared.Fatal(err)
 		}
 
 		fmt.Printf("[+] Retrieved information about role:\n Role ARN: %s\n Role name: %s\n Assume role policy document:\n%s", *role.Role.Arn, *role.Role.RoleName, shared.ParseJsonPolicyDocument(*role.Role.AssumeRolePolicyDocument))
@@ -255,7 +254,7 @@ var EnumRolePoliciesCmd = &cobra.Command{
 
 		policies, err := wrapper.ListRolePoliciesWrapper(ctx, roleName)
 		if err != nil {
-			log.Fatal(err)
+			shared.Fatal(err)
 		}
 
 		fmt.Println("[+] Found following role policies:")
@@ -275,7 +274,7 @@ var EnumRolePolicyDocumentCmd = &cobra.Command{
 
 		policyDocument, err := wrapper.GetRolePolicyDocumentWrapper(ctx, roleName, policyName)
 		if err != nil {
-			log.Fatal(err)
+			shared.Fatal(err)
 		}
 
 		fmt.Printf("[+] Found policy document:\n%s", policyDocument)
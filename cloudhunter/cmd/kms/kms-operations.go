@@ -0,0 +1,234 @@
+package kms
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/Kimi99/cloudhunter/internal/aws"
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/spf13/cobra"
+)
+
+var region string
+var profile string
+var allRegions bool
+var withScps bool
+var ctx = context.TODO()
+
+const decryptAction = "kms:Decrypt"
+
+// caller is the principal whose decrypt rights are worked out for every key.
+type caller struct {
+	sessionArn   string
+	principalArn string
+	policies     []shared.NamedPolicy
+	policiesRead bool
+	scps         []shared.ScpLevel
+}
+
+var EnumKeysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Retrieve KMS keys with their aliases, key policies and grants, and show which ones the caller can decrypt with",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Starting KMS key enumeration...")
+
+		current := resolveCaller()
+
+		for _, scanRegion := range shared.RegionsToScan(region, allRegions) {
+			if allRegions {
+				fmt.Printf("[!] Region: %s\n", scanRegion)
+			}
+
+			wrapper := aws.InitializeKmsWrapper(ctx, scanRegion, profile)
+			keys, err := wrapper.ListKeysWrapper(ctx)
+			if err != nil {
+				if !allRegions {
+					shared.Fatal(err)
+				}
+				log.Printf("[!] Skipping region %s: %v", scanRegion, err)
+				continue
+			}
+
+			aliases, err := wrapper.ListAliasesWrapper(ctx)
+			if err != nil {
+				log.Printf("[!] Couldn't list aliases: %v", err)
+			}
+
+			for _, key := range keys {
+				printKey(wrapper, key, aliases[awssdk.ToString(key.KeyId)], current)
+			}
+		}
+	},
+}
+
+func printKey(wrapper aws.KmsWrapper, key types.KeyListEntry, aliases []string, current caller) {
+	keyArn := awssdk.ToString(key.KeyArn)
+
+	fmt.Printf("[+] Found key!\n Key ID: %s\n ARN: %s\n Aliases: %s\n", awssdk.ToString(key.KeyId), keyArn, strings.Join(aliases, ", "))
+
+	metadata, err := wrapper.DescribeKeyWrapper(ctx, keyArn)
+	if err != nil {
+		log.Printf("[!] Couldn't describe key %s: %v", keyArn, err)
+	} else {
+		fmt.Printf(" Description: %s\n Manager: %s\n State: %s\n Usage: %s\n Spec: %s\n Origin: %s\n",
+			awssdk.ToString(metadata.Description), metadata.KeyManager, metadata.KeyState, metadata.KeyUsage, metadata.KeySpec, metadata.Origin)
+	}
+
+	var keyPolicy *shared.PolicyDocument
+	policy, err := wrapper.GetKeyPolicyWrapper(ctx, keyArn)
+	if err != nil {
+		log.Printf("[!] Couldn't retrieve key policy of %s: %v", keyArn, err)
+	} else {
+		fmt.Printf(" Key policy:\n%s\n", shared.IndentedOrRaw(policy, "key policy of "+keyArn))
+		if parsed, err := shared.ParsePolicyDocument(policy); err == nil {
+			keyPolicy = &parsed
+		}
+	}
+
+	grants, err := wrapper.ListGrantsWrapper(ctx, keyArn)
+	if err != nil {
+		log.Printf("[!] Couldn't list grants of %s: %v", keyArn, err)
+	}
+	for _, grant := range grants {
+		fmt.Printf(" Grant %s: %s can %v (retiring principal: %s)\n",
+			awssdk.ToString(grant.GrantId), awssdk.ToString(grant.GranteePrincipal), grant.Operations, awssdk.ToString(grant.RetiringPrincipal))
+	}
+
+	fmt.Printf(" Caller can decrypt: %s\n\n", decryptAccess(keyArn, keyPolicy, grants, current))
+}
+
+// decryptAccess works out whether the caller may use the key for decryption, either through
+// a grant, the key policy naming the caller, or the key policy delegating to IAM together
+// with an identity policy that allows kms:Decrypt on the key. On that last path the key
+// policy's denies are evaluated together with the identity policies. Conditions aren't
+// evaluated, access that depends on them is reported as maybe together with the condition keys.
+func decryptAccess(keyArn string, keyPolicy *shared.PolicyDocument, grants []types.GrantListEntry, current caller) string {
+	var reasons []string
+	var conditions []string
+
+	for _, grant := range grants {
+		grantee := awssdk.ToString(grant.GranteePrincipal)
+		if (grantee == current.principalArn || grantee == current.sessionArn) && slices.Contains(grant.Operations, types.GrantOperationDecrypt) {
+			reasons = append(reasons, "grant "+awssdk.ToString(grant.GrantId))
+		}
+	}
+
+	if keyPolicy == nil {
+		if len(reasons) > 0 {
+			return "yes (" + strings.Join(reasons, ", ") + ") [DECRYPT]"
+		}
+		return "unknown, key policy not readable"
+	}
+
+	// certain is set once a grant or a path without conditions allows decryption.
+	certain := len(reasons) > 0
+
+	switch decision, keyConditions := keyPolicy.EvaluateForPrincipal(decryptAction, keyArn, current.principalArn); decision {
+	case shared.PolicyExplicitDeny:
+		return "no, explicitly denied by the key policy"
+	case shared.PolicyAllow:
+		reasons = append(reasons, "key policy")
+		certain = true
+	case shared.PolicyConditional:
+		reasons = append(reasons, "key policy")
+		conditions = append(conditions, keyConditions...)
+	}
+
+	if delegated, delegationConditions := keyPolicy.DelegatesToAccount(decryptAction, keyArn, current.principalArn); delegated {
+		keyDenies := shared.NamedPolicy{Name: "key policy", Document: keyPolicy.DenyStatementsFor(current.principalArn)}
+		policies := append([]shared.NamedPolicy{keyDenies}, current.policies...)
+		decision, allowedBy, iamConditions := shared.EvaluatePolicies(policies, decryptAction, keyArn)
+		switch {
+		case decision == shared.PolicyExplicitDeny:
+			return "no, explicitly denied by the key policy or an IAM policy"
+		case decision == shared.PolicyAllow && len(delegationConditions) == 0:
+			reasons = append(reasons, allowedBy...)
+			certain = true
+		case decision == shared.PolicyAllow || decision == shared.PolicyConditional:
+			reasons = append(reasons, allowedBy...)
+			conditions = append(conditions, delegationConditions...)
+			conditions = append(conditions, iamConditions...)
+		case !current.policiesRead:
+			reasons = append(reasons, "possibly through IAM policies, which couldn't be read")
+		}
+	}
+
+	if len(reasons) == 0 {
+		return "no"
+	}
+
+	switch decision, reason := shared.EvaluateScps(current.scps, decryptAction, keyArn); decision {
+	case shared.PolicyConditional:
+		return "maybe, conditionally blocked at the org level (" + reason + "; " + strings.Join(reasons, ", ") + ")"
+	case shared.PolicyExplicitDeny, shared.PolicyImplicitDeny:
+		return "no, blocked at the org level (" + reason + ")"
+	}
+
+	if !certain && len(conditions) > 0 {
+		slices.Sort(conditions)
+		return "maybe (conditions: " + strings.Join(slices.Compact(conditions), ", ") + "; " + strings.Join(reasons, ", ") + ")"
+	}
+
+	return "yes (" + strings.Join(reasons, ", ") + ") [DECRYPT]"
+}
+
+// resolveCaller identifies the caller and collects its IAM policies. Failing to read the
+// policies isn't fatal, the key policies and grants are still evaluated.
+func resolveCaller() caller {
+	identity, err := aws.InitializeStsWrapper(ctx, region, profile).GetCallerIdentityWrapper(ctx)
+	if err != nil {
+		shared.Fatal(err)
+	}
+
+	current := caller{sessionArn: awssdk.ToString(identity.Arn)}
+
+	iamWrapper := aws.InitializeIamWrapper(ctx, region, profile)
+	current.principalArn, err = iamWrapper.CallerPrincipalArnWrapper(ctx, current.sessionArn)
+	if err != nil {
+		shared.Fatal(err)
+	}
+
+	current.policies, err = iamWrapper.CollectPrincipalPoliciesWrapper(ctx, current.principalArn)
+	if err != nil {
+		log.Printf("[!] Couldn't collect IAM policies of %s: %v", current.principalArn, err)
+	} else {
+		current.policiesRead = true
+		fmt.Printf("[!] Evaluating decrypt rights of %s with %d IAM policies\n", current.principalArn, len(current.policies))
+	}
+
+	if withScps {
+		current.scps = callerScps(awssdk.ToString(identity.Account))
+	}
+
+	return current
+}
+
+// callerScps collects the SCPs that apply to the caller's account. Reading them needs access
+// to the management or a delegated administrator account, failing is logged and leaves the
+// decisions to the key and IAM policies alone.
+func callerScps(accountId string) []shared.ScpLevel {
+	levels, err := aws.InitializeOrganizationsWrapper(ctx, region, profile).AccountServiceControlPoliciesWrapper(ctx, accountId)
+	if err != nil {
+		log.Printf("[!] Couldn't collect SCPs of account %s, ignoring them: %v", accountId, err)
+		return nil
+	}
+	if levels == nil {
+		fmt.Println("[!] Caller is in the management account, SCPs don't apply")
+		return nil
+	}
+	fmt.Printf("[!] Evaluating decrypt rights against the SCPs of %d organization levels\n", len(levels))
+
+	return levels
+}
+
+func init() {
+	EnumKeysCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumKeysCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	EnumKeysCmd.Flags().BoolVar(&allRegions, "all-regions", false, "Enumerate every region")
+	EnumKeysCmd.Flags().BoolVar(&withScps, "scps", false, "Also evaluate the organization's SCPs, needs Organizations read access (SCP conditions aren't evaluated)")
+}
@@ -0,0 +1,12 @@
+package kms
+
+import "github.com/spf13/cobra"
+
+var KmsCmd = &cobra.Command{
+	Use:   "kms",
+	Short: "Interact with AWS KMS service",
+}
+
+func init() {
+	KmsCmd.AddCommand(EnumKeysCmd)
+}
@@ -0,0 +1,136 @@
+package lambda
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/Kimi99/cloudhunter/internal/aws"
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/spf13/cobra"
+)
+
+var region string
+var profile string
+var allRegions bool
+var localFolder string
+var ctx = context.TODO()
+
+var EnumFunctionsCmd = &cobra.Command{
+	Use:   "functions",
+	Short: "Retrieve Lambda functions with their environment variables, function URLs, policies and event sources",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Starting Lambda function enumeration...")
+
+		for _, scanRegion := range shared.RegionsToScan(region, allRegions) {
+			if allRegions {
+				fmt.Printf("[!] Region: %s\n", scanRegion)
+			}
+
+			wrapper := aws.InitializeLambdaWrapper(ctx, scanRegion, profile)
+			functions, err := wrapper.ListFunctionsWrapper(ctx)
+			if err != nil {
+				if !allRegions {
+					shared.Fatal(err)
+				}
+				log.Printf("[!] Skipping region %s: %v", scanRegion, err)
+				continue
+			}
+
+			for _, function := range functions {
+				printFunction(wrapper, function)
+			}
+		}
+	},
+}
+
+var DownloadCodeCmd = &cobra.Command{
+	Use:   "download-code [function]",
+	Short: "Download and extract the deployment package of a Lambda function",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		wrapper := aws.InitializeLambdaWrapper(ctx, region, profile)
+
+		fmt.Printf("[!] Downloading code of function %s...\n", args[0])
+		extractedPath, err := wrapper.DownloadFunctionCodeWrapper(ctx, args[0], localFolder)
+		if err != nil {
+			shared.Fatal(err)
+		}
+
+		fmt.Printf("[+] Code extracted to %s\n", extractedPath)
+	},
+}
+
+func printFunction(wrapper aws.LambdaWrapper, function types.FunctionConfiguration) {
+	name := awssdk.ToString(function.FunctionName)
+
+	fmt.Printf("[+] Found function!\n Function name: %s\n ARN: %s\n Runtime: %s\n Handler: %s\n Role: %s\n Last modified: %s\n",
+		name, awssdk.ToString(function.FunctionArn), function.Runtime, awssdk.ToString(function.Handler), awssdk.ToString(function.Role), awssdk.ToString(function.LastModified))
+
+	if function.Environment != nil {
+		if function.Environment.Error != nil {
+			fmt.Printf(" Environment: %s\n", awssdk.ToString(function.Environment.Error.Message))
+		}
+
+		keys := make([]string, 0, len(function.Environment.Variables))
+		for key := range function.Environment.Variables {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			value := function.Environment.Variables[key]
+			marker := ""
+			if shared.IsSecretName(key) || len(shared.DetectSecrets(value)) > 0 {
+				marker = " [POSSIBLE SECRET]"
+			}
+			fmt.Printf(" Env: %s=%s%s\n", key, value, marker)
+		}
+	}
+
+	for _, layer := range function.Layers {
+		fmt.Printf(" Layer: %s\n", awssdk.ToString(layer.Arn))
+	}
+
+	urls, err := wrapper.ListFunctionUrlConfigsWrapper(ctx, name)
+	if err != nil {
+		log.Printf("[!] Couldn't list function URLs of %s: %v", name, err)
+	}
+	for _, url := range urls {
+		marker := ""
+		if url.AuthType == types.FunctionUrlAuthTypeNone {
+			marker = " [NO AUTH]"
+		}
+		fmt.Printf(" Function URL: %s (auth: %s)%s\n", awssdk.ToString(url.FunctionUrl), url.AuthType, marker)
+	}
+
+	mappings, err := wrapper.ListEventSourceMappingsWrapper(ctx, name)
+	if err != nil {
+		log.Printf("[!] Couldn't list event source mappings of %s: %v", name, err)
+	}
+	for _, mapping := range mappings {
+		fmt.Printf(" Event source: %s (%s)\n", awssdk.ToString(mapping.EventSourceArn), awssdk.ToString(mapping.State))
+	}
+
+	policy, err := wrapper.GetPolicyWrapper(ctx, name)
+	if err != nil {
+		log.Printf("[!] Couldn't retrieve resource policy of %s: %v", name, err)
+	} else if policy != "" {
+		fmt.Printf(" Resource policy:\n%s\n", shared.IndentedOrRaw(policy, "resource policy of "+name))
+	}
+
+	fmt.Println()
+}
+
+func init() {
+	EnumFunctionsCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumFunctionsCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	EnumFunctionsCmd.Flags().BoolVar(&allRegions, "all-regions", false, "Enumerate every region")
+
+	DownloadCodeCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	DownloadCodeCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	DownloadCodeCmd.Flags().StringVarP(&localFolder, "folder", "f", "lambda-code", "Local folder used to store and extract the deployment package")
+}
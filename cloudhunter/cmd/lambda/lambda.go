@@ -0,0 +1,13 @@
+package lambda
+
+import "github.com/spf13/cobra"
+
+var LambdaCmd = &cobra.Command{
+	Use:   "lambda",
+	Short: "Interact with AWS Lambda service",
+}
+
+func init() {
+	LambdaCmd.AddCommand(EnumFunctionsCmd)
+	LambdaCmd.AddCommand(DownloadCodeCmd)
+}
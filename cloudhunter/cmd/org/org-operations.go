@@ -0,0 +1,306 @@
+package org
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Kimi99/cloudhunter/internal/aws"
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/spf13/cobra"
+)
+
+var region string
+var profile string
+var accountId string
+var actions []string
+var resource string
+var ctx = context.TODO()
+
+var EnumAccountsCmd = &cobra.Command{
+	Use:   "accounts",
+	Short: "Retrieve the organization and every member account",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Starting organization account enumeration...")
+
+		wrapper := aws.InitializeOrganizationsWrapper(ctx, region, profile)
+		organization := describeOrganization(wrapper)
+
+		accounts, err := wrapper.ListAccountsWrapper(ctx)
+		if err != nil {
+			shared.Fatal(err)
+		}
+
+		for _, account := range accounts {
+			marker := ""
+			if awssdk.ToString(account.Id) == awssdk.ToString(organization.MasterAccountId) {
+				marker = " [MANAGEMENT]"
+			}
+
+			fmt.Printf("[+] Found account!%s\n Name: %s\n ID: %s\n Email: %s\n Status: %s\n Joined: %v (%s)\n\n",
+				marker, awssdk.ToString(account.Name), awssdk.ToString(account.Id), awssdk.ToString(account.Email),
+				account.Status, awssdk.ToTime(account.JoinedTimestamp), account.JoinedMethod)
+		}
+	},
+}
+
+var EnumOrganizationalUnitsCmd = &cobra.Command{
+	Use:   "ous",
+	Short: "Retrieve every organizational unit with its path from the root",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Starting organizational unit enumeration...")
+
+		wrapper := aws.InitializeOrganizationsWrapper(ctx, region, profile)
+		roots, err := wrapper.ListRootsWrapper(ctx)
+		if err != nil {
+			shared.Fatal(err)
+		}
+
+		for _, root := range roots {
+			walkOrganizationalUnits(wrapper, awssdk.ToString(root.Id), awssdk.ToString(root.Name))
+		}
+	},
+}
+
+var TreeCmd = &cobra.Command{
+	Use:   "tree",
+	Short: "Render the organization tree of OUs and accounts with the SCPs attached to every node",
+	Run: func(cmd *cobra.Command, args []string) {
+		wrapper := aws.InitializeOrganizationsWrapper(ctx, region, profile)
+		organization := describeOrganization(wrapper)
+
+		roots, err := wrapper.ListRootsWrapper(ctx)
+		if err != nil {
+			shared.Fatal(err)
+		}
+
+		for _, root := range roots {
+			fmt.Printf("%s (%s)%s\n", awssdk.ToString(root.Name), awssdk.ToString(root.Id), attachedScps(wrapper, awssdk.ToString(root.Id)))
+			printTree(wrapper, awssdk.ToString(root.Id), awssdk.ToString(organization.MasterAccountId), "")
+		}
+	},
+}
+
+var EnumScpsCmd = &cobra.Command{
+	Use:   "scps",
+	Short: "Retrieve service control policies with their content and the roots, OUs and accounts they are attached to",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Starting service control policy enumeration...")
+
+		wrapper := aws.InitializeOrganizationsWrapper(ctx, region, profile)
+		policies, err := wrapper.ListServiceControlPoliciesWrapper(ctx)
+		if err != nil {
+			shared.Fatal(err)
+		}
+
+		for _, policy := range policies {
+			policyId := awssdk.ToString(policy.Id)
+			fmt.Printf("[+] Found SCP!\n Name: %s\n ID: %s\n AWS managed: %t\n Description: %s\n",
+				awssdk.ToString(policy.Name), policyId, policy.AwsManaged, awssdk.ToString(policy.Description))
+
+			targets, err := wrapper.ListTargetsForPolicyWrapper(ctx, policyId)
+			if err != nil {
+				log.Printf("[!] Couldn't list targets of SCP %s: %v", policyId, err)
+			}
+			for _, target := range targets {
+				fmt.Printf(" Attached to %s %s (%s)\n", target.Type, awssdk.ToString(target.Name), awssdk.ToString(target.TargetId))
+			}
+
+			content, err := wrapper.GetPolicyContentWrapper(ctx, policyId)
+			if err != nil {
+				log.Printf("[!] Couldn't retrieve content of SCP %s: %v", policyId, err)
+				fmt.Println()
+				continue
+			}
+			fmt.Printf(" Content:\n%s\n\n", shared.IndentedOrRaw(content, "content of SCP "+policyId))
+		}
+	},
+}
+
+var EnumDelegatedAdministratorsCmd = &cobra.Command{
+	Use:   "delegated-admins",
+	Short: "Retrieve the accounts registered as delegated administrators and the services they administer",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Starting delegated administrator enumeration...")
+
+		wrapper := aws.InitializeOrganizationsWrapper(ctx, region, profile)
+		administrators, err := wrapper.ListDelegatedAdministratorsWrapper(ctx)
+		if err != nil {
+			shared.Fatal(err)
+		}
+
+		if len(administrators) == 0 {
+			fmt.Println("[-] No delegated administrators registered")
+			return
+		}
+
+		for _, administrator := range administrators {
+			administratorId := awssdk.ToString(administrator.Id)
+			fmt.Printf("[+] Found delegated administrator!\n Name: %s\n ID: %s\n Email: %s\n Delegated since: %v\n",
+				awssdk.ToString(administrator.Name), administratorId, awssdk.ToString(administrator.Email), awssdk.ToTime(administrator.DelegationEnabledDate))
+
+			services, err := wrapper.ListDelegatedServicesWrapper(ctx, administratorId)
+			if err != nil {
+				log.Printf("[!] Couldn't list delegated services of %s: %v", administratorId, err)
+			}
+			fmt.Printf(" Services: %s\n\n", strings.Join(services, ", "))
+		}
+	},
+}
+
+var EvaluateScpsCmd = &cobra.Command{
+	Use:   "evaluate-scps",
+	Short: "Check which actions the SCPs on the path from the root to an account block",
+	Long:  "Check which actions the SCPs on the path from the root to an account block. Conditions aren't evaluated: actions whose outcome depends on them are reported as conditionally blocked together with the condition keys.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(actions) == 0 {
+			shared.Fatal("[-] At least one --action is required")
+		}
+
+		if accountId == "" {
+			identity, err := aws.InitializeStsWrapper(ctx, region, profile).GetCallerIdentityWrapper(ctx)
+			if err != nil {
+				shared.Fatal(err)
+			}
+			accountId = awssdk.ToString(identity.Account)
+		}
+
+		wrapper := aws.InitializeOrganizationsWrapper(ctx, region, profile)
+		organization := describeOrganization(wrapper)
+		if accountId == awssdk.ToString(organization.MasterAccountId) {
+			fmt.Printf("[!] %s is the management account, SCPs don't apply to it\n", accountId)
+			return
+		}
+
+		levels, err := wrapper.CollectServiceControlPoliciesWrapper(ctx, accountId)
+		if err != nil {
+			shared.Fatal(err)
+		}
+
+		fmt.Printf("[!] Evaluating SCPs of account %s across %d levels\n", accountId, len(levels))
+		for _, action := range actions {
+			switch decision, reason := shared.EvaluateScps(levels, action, resource); decision {
+			case shared.PolicyAllow:
+				fmt.Printf("[+] %s on %s: allowed by SCPs\n", action, resource)
+			case shared.PolicyConditional:
+				fmt.Printf("[!] %s on %s: conditionally blocked at the org level (%s)\n", action, resource, reason)
+			default:
+				fmt.Printf("[-] %s on %s: %s at the org level (%s) [BLOCKED BY SCP]\n", action, resource, decision, reason)
+			}
+		}
+	},
+}
+
+func describeOrganization(wrapper aws.OrganizationsWrapper) *types.Organization {
+	organization, err := wrapper.DescribeOrganizationWrapper(ctx)
+	if err != nil {
+		shared.Fatal(err)
+	}
+
+	fmt.Printf("[!] Organization %s, management account %s (%s), feature set %s\n",
+		awssdk.ToString(organization.Id), awssdk.ToString(organization.MasterAccountId), awssdk.ToString(organization.MasterAccountEmail), organization.FeatureSet)
+
+	return organization
+}
+
+// walkOrganizationalUnits prints every OU below the parent, depth first.
+func walkOrganizationalUnits(wrapper aws.OrganizationsWrapper, parentId string, path string) {
+	units, err := wrapper.ListOrganizationalUnitsWrapper(ctx, parentId)
+	if err != nil {
+		log.Printf("[!] Couldn't list OUs of %s: %v", parentId, err)
+		return
+	}
+
+	for _, unit := range units {
+		unitPath := path + "/" + awssdk.ToString(unit.Name)
+		fmt.Printf("[+] Found OU!\n Name: %s\n ID: %s\n ARN: %s\n Path: %s\n\n",
+			awssdk.ToString(unit.Name), awssdk.ToString(unit.Id), awssdk.ToString(unit.Arn), unitPath)
+
+		walkOrganizationalUnits(wrapper, awssdk.ToString(unit.Id), unitPath)
+	}
+}
+
+// printTree prints the OUs and accounts below the parent, OUs first, with box drawing
+// characters connecting them to their parent.
+func printTree(wrapper aws.OrganizationsWrapper, parentId string, managementAccountId string, indent string) {
+	units, err := wrapper.ListOrganizationalUnitsWrapper(ctx, parentId)
+	if err != nil {
+		log.Printf("[!] Couldn't list OUs of %s: %v", parentId, err)
+	}
+	accounts, err := wrapper.ListAccountsForParentWrapper(ctx, parentId)
+	if err != nil {
+		log.Printf("[!] Couldn't list accounts of %s: %v", parentId, err)
+	}
+
+	children := len(units) + len(accounts)
+	for i, unit := range units {
+		branch, nextIndent := treeBranch(i == children-1, indent)
+		unitId := awssdk.ToString(unit.Id)
+		fmt.Printf("%s%sOU %s (%s)%s\n", indent, branch, awssdk.ToString(unit.Name), unitId, attachedScps(wrapper, unitId))
+		printTree(wrapper, unitId, managementAccountId, nextIndent)
+	}
+
+	for i, account := range accounts {
+		branch, _ := treeBranch(len(units)+i == children-1, indent)
+		id := awssdk.ToString(account.Id)
+
+		marker := ""
+		if id == managementAccountId {
+			marker = " [MANAGEMENT]"
+		}
+		if account.Status != types.AccountStatusActive {
+			marker += fmt.Sprintf(" [%s]", account.Status)
+		}
+
+		fmt.Printf("%s%s%s (%s)%s%s\n", indent, branch, awssdk.ToString(account.Name), id, marker, attachedScps(wrapper, id))
+	}
+}
+
+func treeBranch(last bool, indent string) (string, string) {
+	if last {
+		return "└── ", indent + "    "
+	}
+
+	return "├── ", indent + "│   "
+}
+
+// attachedScps formats the names of the SCPs attached directly to the target.
+func attachedScps(wrapper aws.OrganizationsWrapper, targetId string) string {
+	policies, err := wrapper.ListServiceControlPoliciesForTargetWrapper(ctx, targetId)
+	if err != nil || len(policies) == 0 {
+		return ""
+	}
+
+	var names []string
+	for _, policy := range policies {
+		names = append(names, awssdk.ToString(policy.Name))
+	}
+
+	return " SCPs: " + strings.Join(names, ", ")
+}
+
+func init() {
+	EnumAccountsCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumAccountsCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+
+	EnumOrganizationalUnitsCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumOrganizationalUnitsCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+
+	TreeCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	TreeCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+
+	EnumScpsCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumScpsCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+
+	EnumDelegatedAdministratorsCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumDelegatedAdministratorsCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+
+	EvaluateScpsCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EvaluateScpsCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	EvaluateScpsCmd.Flags().StringVar(&accountId, "account", "", "Account to evaluate the SCPs of (default: the caller's account)")
+	EvaluateScpsCmd.Flags().StringArrayVar(&actions, "action", nil, "Action to check, e.g. s3:GetObject, can be repeated")
+	EvaluateScpsCmd.Flags().StringVar(&resource, "resource", "*", "Resource ARN to check the actions against")
+}
@@ -0,0 +1,17 @@
+package org
+
+import "github.com/spf13/cobra"
+
+var OrgCmd = &cobra.Command{
+	Use:   "org",
+	Short: "Interact with AWS Organizations from the management or a delegated administrator account",
+}
+
+func init() {
+	OrgCmd.AddCommand(EnumAccountsCmd)
+	OrgCmd.AddCommand(EnumOrganizationalUnitsCmd)
+	OrgCmd.AddCommand(TreeCmd)
+	OrgCmd.AddCommand(EnumScpsCmd)
+	OrgCmd.AddCommand(EnumDelegatedAdministratorsCmd)
+	OrgCmd.AddCommand(EvaluateScpsCmd)
+}
@@ -0,0 +1,12 @@
+package rds
+
+import "github.com/spf13/cobra"
+
+var RdsCmd = &cobra.Command{
+	Use:   "rds",
+	Short: "Interact with AWS RDS service",
+}
+
+func init() {
+	RdsCmd.AddCommand(EnumSnapshotsCmd)
+}
@@ -0,0 +1,500 @@
+package recon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/Kimi99/cloudhunter/internal/aws"
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/cobra"
+)
+
+var region string
+var profile string
+var allRegions bool
+var knownAccountsFile string
+var probeRole string
+var probeRoleNames []string
+var confirm bool
+var ctx = context.TODO()
+
+var LoggingCmd = &cobra.Command{
+	Use:   "logging",
+	Short: "Enumerate CloudTrail, GuardDuty, Security Hub, Config, Access Analyzer and CloudWatch alarms and summarize which commands defenders would see",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Starting logging and detection reconnaissance...")
+
+		var coverage shared.LoggingCoverage
+		var guardDutyRegions, unreadableTrailRegions []string
+		seenTrails := make(map[string]bool)
+
+		for _, scanRegion := range shared.RegionsToScan(region, allRegions) {
+			if allRegions {
+				fmt.Printf("[!] Region: %s\n", scanRegion)
+			}
+
+			wrapper := aws.InitializeDetectionWrapper(ctx, scanRegion, profile)
+			// The other services are still worth checking when the trails can't be read.
+			trailCoverage, err := reportTrails(wrapper, seenTrails)
+			if err != nil {
+				log.Printf("[!] Couldn't describe trails in %s: %v", scanRegion, err)
+				unreadableTrailRegions = append(unreadableTrailRegions, scanRegion)
+			}
+			coverage = coverage.Merge(trailCoverage)
+
+			if reportGuardDuty(wrapper) {
+				guardDutyRegions = append(guardDutyRegions, wrapper.GuardDutyClient.Options().Region)
+			}
+			reportSecurityHub(wrapper)
+			reportConfig(wrapper)
+			reportAccessAnalyzer(wrapper)
+			reportAlarms(wrapper)
+		}
+
+		printVisibilitySummary(coverage, guardDutyRegions, unreadableTrailRegions)
+	},
+}
+
+// reportTrails prints the trails of the region that weren't seen in an earlier region and
+// returns what the active ones record.
+func reportTrails(wrapper aws.DetectionWrapper, seenTrails map[string]bool) (shared.LoggingCoverage, error) {
+	var coverage shared.LoggingCoverage
+
+	trails, err := wrapper.DescribeTrailsWrapper(ctx)
+	if err != nil {
+		return coverage, err
+	}
+
+	for _, trail := range trails {
+		trailArn := awssdk.ToString(trail.TrailARN)
+		if seenTrails[trailArn] {
+			continue
+		}
+		seenTrails[trailArn] = true
+
+		logging := "unknown"
+		status, err := wrapper.GetTrailStatusWrapper(ctx, trailArn)
+		if err != nil {
+			log.Printf("[!] Couldn't retrieve status of trail %s: %v", trailArn, err)
+		} else {
+			logging = fmt.Sprint(awssdk.ToBool(status.IsLogging))
+		}
+
+		fmt.Printf("[+] Found trail!\n Name: %s\n ARN: %s\n Home region: %s\n Logging: %s\n Multi-region: %t\n Organization trail: %t\n Global service events: %t\n Log file validation: %t\n Destination: s3://%s/%s\n",
+			awssdk.ToString(trail.Name), trailArn, awssdk.ToString(trail.HomeRegion), logging, awssdk.ToBool(trail.IsMultiRegionTrail), awssdk.ToBool(trail.IsOrganizationTrail),
+			awssdk.ToBool(trail.IncludeGlobalServiceEvents), awssdk.ToBool(trail.LogFileValidationEnabled), awssdk.ToString(trail.S3BucketName), awssdk.ToString(trail.S3KeyPrefix))
+		if trail.CloudWatchLogsLogGroupArn != nil {
+			fmt.Printf(" CloudWatch Logs: %s\n", awssdk.ToString(trail.CloudWatchLogsLogGroupArn))
+		}
+		if trail.KmsKeyId != nil {
+			fmt.Printf(" KMS key: %s\n", awssdk.ToString(trail.KmsKeyId))
+		}
+
+		selectors, err := wrapper.GetEventSelectorsWrapper(ctx, trailArn)
+		if err != nil {
+			log.Printf("[!] Couldn't retrieve event selectors of trail %s: %v", trailArn, err)
+			fmt.Println()
+			continue
+		}
+
+		trailCoverage := aws.TrailCoverage(selectors)
+		fmt.Printf(" Management events: %s\n S3 data events: %s\n Lambda data events: %t\n\n",
+			readWrite(trailCoverage.ManagementRead, trailCoverage.ManagementWrite), readWrite(trailCoverage.S3DataRead, trailCoverage.S3DataWrite), trailCoverage.LambdaData)
+
+		if status != nil && awssdk.ToBool(status.IsLogging) {
+			coverage = coverage.Merge(trailCoverage)
+		}
+	}
+
+	return coverage, nil
+}
+
+// reportGuardDuty prints the GuardDuty detectors and reports whether one of them is enabled.
+func reportGuardDuty(wrapper aws.DetectionWrapper) bool {
+	detectorIds, err := wrapper.ListDetectorsWrapper(ctx)
+	if err != nil {
+		log.Printf("[!] Couldn't list GuardDuty detectors: %v", err)
+		return false
+	}
+	if len(detectorIds) == 0 {
+		fmt.Println("[-] GuardDuty is not enabled")
+	}
+
+	enabled := false
+	for _, detectorId := range detectorIds {
+		detector, err := wrapper.GetDetectorWrapper(ctx, detectorId)
+		if err != nil {
+			log.Printf("[!] Couldn't retrieve GuardDuty detector %s: %v", detectorId, err)
+			continue
+		}
+
+		enabled = enabled || detector.Status == "ENABLED"
+		fmt.Printf("[+] Found GuardDuty detector!\n Detector ID: %s\n Status: %s\n Finding frequency: %s\n", detectorId, detector.Status, detector.FindingPublishingFrequency)
+		for _, feature := range detector.Features {
+			fmt.Printf(" Feature %s: %s\n", feature.Name, feature.Status)
+		}
+		fmt.Println()
+	}
+
+	return enabled
+}
+
+func reportSecurityHub(wrapper aws.DetectionWrapper) {
+	hub, err := wrapper.DescribeHubWrapper(ctx)
+	if err != nil {
+		log.Printf("[!] Couldn't describe Security Hub: %v", err)
+		return
+	}
+	if hub == nil {
+		fmt.Println("[-] Security Hub is not enabled")
+		return
+	}
+
+	fmt.Printf("[+] Security Hub is enabled!\n Hub ARN: %s\n Subscribed at: %s\n", awssdk.ToString(hub.HubArn), awssdk.ToString(hub.SubscribedAt))
+	standards, err := wrapper.GetEnabledStandardsWrapper(ctx)
+	if err != nil {
+		log.Printf("[!] Couldn't list enabled Security Hub standards: %v", err)
+	}
+	for _, standard := range standards {
+		fmt.Printf(" Standard: %s (%s)\n", awssdk.ToString(standard.StandardsArn), standard.StandardsStatus)
+	}
+	fmt.Println()
+}
+
+func reportConfig(wrapper aws.DetectionWrapper) {
+	recorders, err := wrapper.DescribeConfigurationRecordersWrapper(ctx)
+	if err != nil {
+		log.Printf("[!] Couldn't describe Config recorders: %v", err)
+		return
+	}
+	if len(recorders) == 0 {
+		fmt.Println("[-] AWS Config is not recording")
+		return
+	}
+
+	statuses, err := wrapper.DescribeConfigurationRecorderStatusWrapper(ctx)
+	if err != nil {
+		log.Printf("[!] Couldn't retrieve Config recorder status: %v", err)
+	}
+	recording := make(map[string]bool)
+	for _, status := range statuses {
+		recording[awssdk.ToString(status.Name)] = status.Recording
+	}
+
+	for _, recorder := range recorders {
+		allSupported := recorder.RecordingGroup != nil && recorder.RecordingGroup.AllSupported
+		fmt.Printf("[+] Found Config recorder!\n Name: %s\n Recording: %t\n All resource types: %t\n\n",
+			awssdk.ToString(recorder.Name), recording[awssdk.ToString(recorder.Name)], allSupported)
+	}
+}
+
+func reportAccessAnalyzer(wrapper aws.DetectionWrapper) {
+	analyzers, err := wrapper.ListAnalyzersWrapper(ctx)
+	if err != nil {
+		log.Printf("[!] Couldn't list Access Analyzer analyzers: %v", err)
+		return
+	}
+	if len(analyzers) == 0 {
+		fmt.Println("[-] IAM Access Analyzer has no analyzers")
+	}
+
+	for _, analyzer := range analyzers {
+		fmt.Printf("[+] Found Access Analyzer!\n Name: %s\n Type: %s\n Status: %s\n Last resource analyzed: %s\n\n",
+			awssdk.ToString(analyzer.Name), analyzer.Type, analyzer.Status, awssdk.ToString(analyzer.LastResourceAnalyzed))
+	}
+}
+
+func reportAlarms(wrapper aws.DetectionWrapper) {
+	alarms, err := wrapper.DescribeAlarmsWrapper(ctx)
+	if err != nil {
+		log.Printf("[!] Couldn't list CloudWatch alarms: %v", err)
+		return
+	}
+
+	for _, alarm := range alarms {
+		// Alarms outside the AWS/ namespaces are usually built on log metric
+		// filters, the classic CIS way of alerting on CloudTrail events.
+		marker := ""
+		if !strings.HasPrefix(awssdk.ToString(alarm.Namespace), "AWS/") {
+			marker = " [LOG METRIC FILTER]"
+		}
+
+		fmt.Printf("[+] Found CloudWatch alarm!%s\n Name: %s\n Metric: %s/%s\n State: %s\n Actions: %v\n\n",
+			marker, awssdk.ToString(alarm.AlarmName), awssdk.ToString(alarm.Namespace), awssdk.ToString(alarm.MetricName), alarm.StateValue, alarm.AlarmActions)
+	}
+}
+
+// printVisibilitySummary prints, for every command, which of its API calls end up in an active trail.
+func printVisibilitySummary(coverage shared.LoggingCoverage, guardDutyRegions []string, unreadableTrailRegions []string) {
+	fmt.Println("[!] Command visibility summary:")
+	fmt.Printf(" Active trails record management events: %s, S3 data events: %s\n",
+		readWrite(coverage.ManagementRead, coverage.ManagementWrite), readWrite(coverage.S3DataRead, coverage.S3DataWrite))
+	if len(unreadableTrailRegions) > 0 {
+		fmt.Printf(" Trails couldn't be read in %s, so the summary may miss trails that record your commands\n", strings.Join(unreadableTrailRegions, ", "))
+	}
+	if len(guardDutyRegions) > 0 {
+		fmt.Printf(" GuardDuty is enabled in %s and may flag anomalous API usage\n", strings.Join(guardDutyRegions, ", "))
+	}
+	if !allRegions {
+		fmt.Println(" Only one region was checked, use --all-regions for the full picture")
+	}
+	fmt.Println()
+
+	for _, activity := range shared.CommandActivities {
+		fmt.Printf(" %s: %s\n", activity.Command, coverage.Visibility(activity))
+	}
+}
+
+func readWrite(read bool, write bool) string {
+	switch {
+	case read && write:
+		return "read and write"
+	case read:
+		return "read only"
+	case write:
+		return "write only"
+	default:
+		return "none"
+	}
+}
+
+var AccountsCmd = &cobra.Command{
+	Use:   "accounts",
+	Short: "Collect foreign account IDs from the account's policies, label known AWS and vendor accounts and optionally confirm they exist",
+	Long:  "Collect the account IDs named in role trust policies, IAM identity policies, bucket policies, access point policies and KMS key policies, and label known AWS and vendor accounts. With --probe-role, every principal is checked by adding a statement denying it to the role's trust policy, which keeps trusting everyone it did. The original trust policy is restored afterwards, also on Ctrl+C or SIGTERM.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if probeRole != "" && !confirm {
+			shared.Fatalf("[-] Probing adds statements to the trust policy of role %s for a moment, pass --confirm to go ahead", probeRole)
+		}
+
+		known, err := shared.LoadKnownAccounts(knownAccountsFile)
+		if err != nil {
+			shared.Fatal(err)
+		}
+
+		fmt.Println("[!] Starting foreign account reconnaissance...")
+
+		identity, err := aws.InitializeStsWrapper(ctx, region, profile).GetCallerIdentityWrapper(ctx)
+		if err != nil {
+			shared.Fatal(err)
+		}
+		ownAccount := awssdk.ToString(identity.Account)
+
+		iamWrapper := aws.InitializeIamWrapper(ctx, region, profile)
+		sightings := shared.NewAccountSightings()
+		collectTrustPolicyAccounts(iamWrapper, sightings)
+		collectIdentityPolicyAccounts(iamWrapper, sightings)
+		collectBucketPolicyAccounts(sightings)
+		collectAccessPointPolicyAccounts(sightings)
+		collectKeyPolicyAccounts(sightings)
+
+		var foreign []string
+		for _, accountId := range sightings.Order {
+			if accountId != ownAccount {
+				foreign = append(foreign, accountId)
+			}
+		}
+
+		if len(foreign) == 0 {
+			fmt.Println("[-] No foreign account IDs found")
+			return
+		}
+
+		// Ctrl+C and SIGTERM stop the probes, the trust policy is restored once the loop is left.
+		probeCtx, stopProbing := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stopProbing()
+
+		probe := func(accountId string) {}
+		if probeRole != "" {
+			trustPolicy, err := iamWrapper.GetRoleTrustPolicyWrapper(ctx, probeRole)
+			if err != nil {
+				shared.Fatal(err)
+			}
+			fmt.Printf("[!] Probing principals through the trust policy of role %s, it will be restored afterwards\n", probeRole)
+			defer restoreTrustPolicy(iamWrapper, trustPolicy)
+
+			partition := "aws"
+			if parsed := strings.SplitN(awssdk.ToString(identity.Arn), ":", 3); len(parsed) == 3 {
+				partition = parsed[1]
+			}
+			probe = func(accountId string) { probeAccount(probeCtx, iamWrapper, trustPolicy, partition, accountId) }
+		}
+
+		for _, accountId := range foreign {
+			if probeCtx.Err() != nil {
+				fmt.Println("[!] Interrupted, stopping")
+				break
+			}
+
+			label := "unknown"
+			marker := ""
+			if account, ok := known[accountId]; ok {
+				label = account.String()
+				marker = " [KNOWN]"
+			}
+
+			fmt.Printf("[+] Found foreign account!%s\n ID: %s\n Owner: %s\n Seen in: %s\n",
+				marker, accountId, label, strings.Join(sightings.Sources[accountId], ", "))
+			probe(accountId)
+			fmt.Println()
+		}
+	},
+}
+
+// collectTrustPolicyAccounts records the accounts named in the trust policies of every role.
+func collectTrustPolicyAccounts(wrapper aws.IamWrapper, sightings *shared.AccountSightings) {
+	roles, err := wrapper.ListAllRolesWrapper(ctx)
+	if err != nil {
+		log.Printf("[!] Couldn't list roles: %v", err)
+	}
+
+	for _, role := range roles {
+		document, err := url.QueryUnescape(awssdk.ToString(role.AssumeRolePolicyDocument))
+		if err != nil {
+			log.Printf("[!] Couldn't decode trust policy of role %s: %v", awssdk.ToString(role.RoleName), err)
+			continue
+		}
+		sightings.Add("trust policy of role "+awssdk.ToString(role.RoleName), document)
+	}
+}
+
+// collectBucketPolicyAccounts records the accounts named in the policies of every bucket.
+func collectBucketPolicyAccounts(sightings *shared.AccountSightings) {
+	wrapper := aws.InitializeS3Wrapper(ctx, region, profile, false)
+	buckets, err := wrapper.ListBuckets(ctx)
+	if err != nil {
+		log.Printf("[!] Couldn't list buckets: %v", err)
+	}
+
+	for _, bucket := range buckets {
+		name := awssdk.ToString(bucket.Name)
+		policy, err := wrapper.GetBucketPolicy(ctx, name)
+		if err != nil {
+			log.Printf("[!] Couldn't retrieve bucket policy of %s: %v", name, err)
+			continue
+		}
+		if policy != "" {
+			sightings.Add("bucket policy of "+name, policy)
+		}
+	}
+}
+
+// collectIdentityPolicyAccounts records the accounts named in inline and customer managed
+// identity policies, e.g. roles of other accounts that principals may assume.
+func collectIdentityPolicyAccounts(wrapper aws.IamWrapper, sightings *shared.AccountSightings) {
+	policies, err := wrapper.ListIdentityPoliciesWrapper(ctx)
+	if err != nil {
+		log.Printf("[!] Couldn't read identity policies: %v", err)
+	}
+
+	for _, policy := range policies {
+		document, err := json.Marshal(policy.Document)
+		if err != nil {
+			continue
+		}
+		sightings.Add(policy.Name, string(document))
+	}
+}
+
+// collectAccessPointPolicyAccounts records the accounts named in the policies of the access
+// points of the region, including Object Lambda and Multi-Region Access Points.
+func collectAccessPointPolicyAccounts(sightings *shared.AccountSightings) {
+	wrapper := aws.InitializeS3ControlWrapper(ctx, region, profile)
+
+	var accessPoints []shared.AccessPoint
+	for _, list := range []func() ([]shared.AccessPoint, error){
+		func() ([]shared.AccessPoint, error) { return wrapper.ListAccessPointsWrapper(ctx, region) },
+		func() ([]shared.AccessPoint, error) { return wrapper.ListObjectLambdaAccessPointsWrapper(ctx, region) },
+		func() ([]shared.AccessPoint, error) { return wrapper.ListMultiRegionAccessPointsWrapper(ctx) },
+	} {
+		found, err := list()
+		if err != nil {
+			log.Printf("[!] Couldn't list access points: %v", err)
+		}
+		accessPoints = append(accessPoints, found...)
+	}
+
+	for _, accessPoint := range accessPoints {
+		// Missing or unreadable policies come back as a note in parentheses.
+		if strings.HasPrefix(accessPoint.Policy, "(") {
+			continue
+		}
+		sightings.Add("policy of access point "+accessPoint.Name, accessPoint.Policy)
+	}
+}
+
+// collectKeyPolicyAccounts records the accounts named in the policies of the KMS keys of the region.
+func collectKeyPolicyAccounts(sightings *shared.AccountSightings) {
+	wrapper := aws.InitializeKmsWrapper(ctx, region, profile)
+	keys, err := wrapper.ListKeysWrapper(ctx)
+	if err != nil {
+		log.Printf("[!] Couldn't list KMS keys: %v", err)
+	}
+
+	for _, key := range keys {
+		keyId := awssdk.ToString(key.KeyId)
+		policy, err := wrapper.GetKeyPolicyWrapper(ctx, keyId)
+		if err != nil {
+			log.Printf("[!] Couldn't retrieve key policy of %s: %v", keyId, err)
+			continue
+		}
+		sightings.Add("policy of KMS key "+keyId, policy)
+	}
+}
+
+// restoreTrustPolicy puts the original trust policy of the probe role back. If that fails the
+// policy is printed so it can be put back by hand.
+func restoreTrustPolicy(wrapper aws.IamWrapper, original string) {
+	if err := wrapper.UpdateAssumeRolePolicyWrapper(ctx, probeRole, original); err != nil {
+		log.Printf("[-] Couldn't restore the trust policy of role %s, restore it manually: %v\n%s", probeRole, err, original)
+		return
+	}
+
+	fmt.Printf("[!] Restored the trust policy of role %s\n", probeRole)
+}
+
+// probeAccount checks whether the account and the roles named with --probe-roles exist in it.
+// It stops early when probeCtx is cancelled.
+func probeAccount(probeCtx context.Context, wrapper aws.IamWrapper, trustPolicy string, partition string, accountId string) {
+	principals := []string{fmt.Sprintf("arn:%s:iam::%s:root", partition, accountId)}
+	for _, roleName := range probeRoleNames {
+		principals = append(principals, fmt.Sprintf("arn:%s:iam::%s:role/%s", partition, accountId, roleName))
+	}
+
+	for _, principal := range principals {
+		if probeCtx.Err() != nil {
+			return
+		}
+
+		exists, err := wrapper.PrincipalExistsWrapper(probeCtx, probeRole, trustPolicy, principal)
+		switch {
+		case err != nil:
+			fmt.Printf(" %s: unknown (%v)\n", principal, err)
+		case exists:
+			fmt.Printf(" %s: exists [EXISTS]\n", principal)
+		default:
+			fmt.Printf(" %s: doesn't exist\n", principal)
+		}
+	}
+}
+
+func init() {
+	LoggingCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	LoggingCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	LoggingCmd.Flags().BoolVar(&allRegions, "all-regions", false, "Enumerate every region")
+
+	AccountsCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	AccountsCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	AccountsCmd.Flags().StringVar(&knownAccountsFile, "known-accounts", "", "JSON file with extra known accounts, in the format of the bundled list")
+	AccountsCmd.Flags().StringVar(&probeRole, "probe-role", "", "Role of our own account whose trust policy is used to check which accounts and roles exist")
+	AccountsCmd.Flags().StringSliceVar(&probeRoleNames, "probe-roles", []string{"OrganizationAccountAccessRole"}, "Role names to check in every foreign account when probing")
+	AccountsCmd.Flags().BoolVar(&confirm, "confirm", false, "Confirm that --probe-role may temporarily add statements to the role's trust policy")
+}
@@ -0,0 +1,13 @@
+package recon
+
+import "github.com/spf13/cobra"
+
+var ReconCmd = &cobra.Command{
+	Use:   "recon",
+	Short: "Assess the environment before running noisier enumeration",
+}
+
+func init() {
+	ReconCmd.AddCommand(LoggingCmd)
+	ReconCmd.AddCommand(AccountsCmd)
+}
@@ -1,15 +1,58 @@
 package cmd
 
 import (
+	"os"
+	"strings"
+
+	"github.com/Kimi99/cloudhunter/cmd/ec2"
 	"github.com/Kimi99/cloudhunter/cmd/iam"
+	"github.com/Kimi99/cloudhunter/cmd/kms"
+	"github.com/Kimi99/cloudhunter/cmd/lambda"
+	"github.com/Kimi99/cloudhunter/cmd/org"
+	"github.com/Kimi99/cloudhunter/cmd/rds"
+	"github.com/Kimi99/cloudhunter/cmd/recon"
 	"github.com/Kimi99/cloudhunter/cmd/s3"
+	"github.com/Kimi99/cloudhunter/cmd/secrets"
+	"github.com/Kimi99/cloudhunter/cmd/ssm"
+	"github.com/Kimi99/cloudhunter/internal/shared"
 	"github.com/spf13/cobra"
 )
 
+var plan bool
+var quietLevel string
+var endpointUrls []string
+
 var rootCmd = &cobra.Command{
 	Use:   "cloudhunter",
 	Short: "CloudHunter - AWS post-compromise enumeration tool",
 	Long:  "CloudHunter is a CLI tool for mapping AWS environments using stolen or assumed credentials in post-compromise or red team scenarios.",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		level, err := shared.ParseNoiseLevel(quietLevel)
+		if err != nil {
+			shared.Fatal(err)
+		}
+		shared.QuietLevel = level
+
+		if err := shared.ParseEndpointUrls(endpointUrls); err != nil {
+			shared.Fatal(err)
+		}
+
+		if err := shared.ValidateTransportOptions(); err != nil {
+			shared.Fatal(err)
+		}
+
+		if shared.RequestRate < 0 || shared.MaxRetries < 0 {
+			shared.Fatal("[-] --rate and --max-retries can't be negative")
+		}
+
+		if plan {
+			shared.PrintPlan(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "))
+			os.Exit(0)
+		}
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		shared.PrintRunSummary()
+	},
 }
 
 func Execute() {
@@ -17,6 +60,26 @@ func Execute() {
 }
 
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&plan, "plan", false, "Print the API calls the command would make with their noise rating and GuardDuty findings, without running it")
+	rootCmd.PersistentFlags().StringVar(&quietLevel, "quiet-level", "", "Refuse API calls rated above this noise level (low, medium or high)")
+	rootCmd.PersistentFlags().Float64Var(&shared.RequestRate, "rate", 0, "Maximum API requests per second across all clients, 0 for no limit")
+	rootCmd.PersistentFlags().DurationVar(&shared.RequestJitter, "jitter", 0, "Random extra delay of up to this duration before every request, e.g. 500ms")
+	rootCmd.PersistentFlags().IntVar(&shared.MaxRetries, "max-retries", shared.MaxRetries, "Maximum retries of a failed request, with adaptive backoff on throttling")
+	rootCmd.PersistentFlags().StringArrayVar(&endpointUrls, "endpoint-url", nil, "Send requests to this endpoint instead of AWS, or service=URL for a single service (e.g. s3=http://localhost:9000), can be repeated")
+	rootCmd.PersistentFlags().BoolVar(&shared.S3PathStyle, "path-style", false, "Use path-style S3 addressing (endpoint/bucket), needed by most S3-compatible servers")
+	rootCmd.PersistentFlags().BoolVar(&shared.InsecureTLS, "insecure-tls", false, "Skip TLS certificate verification, e.g. for self-signed test endpoints")
+	rootCmd.PersistentFlags().StringVar(&shared.ProxyUrl, "proxy", "", "Send all traffic through this proxy, e.g. http://127.0.0.1:8080 or socks5://127.0.0.1:1080")
+	rootCmd.PersistentFlags().StringVar(&shared.CaBundle, "ca-bundle", "", "PEM file with extra CA certificates to trust, e.g. an intercepting proxy's")
+	rootCmd.PersistentFlags().StringVar(&shared.UserAgent, "user-agent", "", "User-Agent to send: aws-cli, boto3, console or a custom string (default: the Go SDK's)")
+
 	rootCmd.AddCommand(iam.IamCmd)
 	rootCmd.AddCommand(s3.S3Cmd)
+	rootCmd.AddCommand(ec2.Ec2Cmd)
+	rootCmd.AddCommand(rds.RdsCmd)
+	rootCmd.AddCommand(lambda.LambdaCmd)
+	rootCmd.AddCommand(secrets.SecretsCmd)
+	rootCmd.AddCommand(ssm.SsmCmd)
+	rootCmd.AddCommand(kms.KmsCmd)
+	rootCmd.AddCommand(org.OrgCmd)
+	rootCmd.AddCommand(recon.ReconCmd)
 }
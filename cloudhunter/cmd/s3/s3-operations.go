@@ -2,11 +2,22 @@ package s3
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/Kimi99/cloudhunter/internal/aws"
 	"github.com/Kimi99/cloudhunter/internal/shared"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +26,35 @@ var profile string
 var bucketName string
 var anonymousMode bool
 var localFolder string
+var keywords []string
+var keywordsFile string
+var threads int
+var showMissing bool
+var allowWrites bool
+var longListing bool
+var withMetadata bool
+var treeView bool
+var listPrefix string
+var maxDepth int
+var maxObjects int
+var listWorkers int
+var objectKey string
+var keyGlob string
+var presignExpiry time.Duration
+var manifestFile string
+var requesterPays bool
+var expectedOwner string
+var byteRange string
+var headBytes int64
+var maxOutput int64
+var rawOutput bool
+var snapshotFile string
+var olderSnapshotFile string
+var newerSnapshotFile string
+var fetchChanged bool
+var allRegions bool
+var reportFile string
+var withScps bool
 var ctx = context.TODO()
 
 var ListBucketContentCmd = &cobra.Command{
@@ -23,17 +63,56 @@ var ListBucketContentCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("[!] Retrieving data from bucket...")
 
-		wrapper := aws.InitializeS3Wrapper(ctx, region, profile, anonymousMode)
+		wrapper := initializeS3Wrapper()
 
-		objects, err := wrapper.ListS3BucketContent(ctx, bucketName, "")
-		if err != nil {
-			log.Fatal(err)
+		// Metadata is collected once the tree is complete, so it is always rendered as a tree.
+		renderTree := treeView || withMetadata
+
+		listCtx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+
+		opts := aws.ListContentOptions{
+			MaxDepth:   maxDepth,
+			MaxObjects: maxObjects,
+			Workers:    listWorkers,
+		}
+		if !renderTree {
+			opts.OnNode = func(node *shared.S3Node, depth int) {
+				shared.RenderBucketNode(node, longListing)
+			}
 		}
 
-		if len(objects) != 0 {
-			shared.RenderBucketContent(objects, "  ")
-		} else {
+		objects, err := wrapper.ListS3BucketContent(listCtx, bucketName, listPrefix, opts)
+		switch {
+		case errors.Is(err, context.Canceled):
+			fmt.Println("[!] Listing interrupted, showing partial results.")
+		case errors.Is(err, aws.ErrObjectBudgetReached):
+			fmt.Printf("[!] Stopped after %d objects (--max-objects), showing partial results.\n", maxObjects)
+		case err != nil:
+			shared.Fatal(err)
+		}
+		stop()
+		reportRequesterPays(wrapper)
+
+		if withMetadata && err == nil {
+			fmt.Println("[!] Retrieving object metadata and tags...")
+			wrapper.PopulateObjectMetadata(ctx, bucketName, objects)
+		}
+
+		if len(objects) == 0 {
 			fmt.Println("[-] No content is present in the bucket!")
+			return
+		}
+
+		switch {
+		case renderTree && (longListing || withMetadata):
+			shared.RenderBucketContentLong(objects, "  ")
+		case renderTree:
+			shared.RenderBucketContent(objects, "  ")
+		case longListing:
+			// Streamed folders are printed before their content is known, so their totals follow the listing.
+			fmt.Println("[!] Folder totals:")
+			shared.RenderFolderTotals(objects)
 		}
 	},
 }
@@ -44,7 +123,7 @@ var ListBucketsCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("[!] Retrieving list of S3 buckets...")
 
-		wrapper := aws.InitializeS3Wrapper(ctx, region, profile, anonymousMode)
+		wrapper := initializeS3Wrapper()
 
 		buckets, err := wrapper.ListBuckets(ctx)
 		if err != nil {
@@ -55,7 +134,11 @@ var ListBucketsCmd = &cobra.Command{
 		if len(buckets) != 0 {
 			fmt.Println("[+] Found following buckets on the account:")
 			for _, bucket := range buckets {
-				fmt.Printf("%s\n", *bucket.Name)
+				bucketRegion, err := wrapper.ResolveBucketRegion(ctx, *bucket.Name)
+				if err != nil {
+					bucketRegion = "unknown"
+				}
+				fmt.Printf("%s (region: %s)\n", *bucket.Name, bucketRegion)
 			}
 		} else {
 			fmt.Println("[+] No S3 buckets are present on the account!")
@@ -69,29 +152,686 @@ var DumpBucketCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("[!] Retrieving contents of the bucket...")
 
-		wrapper := aws.InitializeS3Wrapper(ctx, region, profile, anonymousMode)
+		wrapper := initializeS3Wrapper()
 
 		err := wrapper.DumpBucketWrapper(ctx, bucketName, localFolder)
+		reportRequesterPays(wrapper)
 		if err != nil {
-			log.Fatal(err)
+			shared.Fatal(err)
 		} else {
 			fmt.Printf("[+] Dumped contents of S3 bucket to local folder: %s", localFolder)
 		}
 	},
 }
 
+var DiscoverBucketsCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "Discover S3 buckets by generating name permutations from company keywords and probing them anonymously",
+	Run: func(cmd *cobra.Command, args []string) {
+		if keywordsFile != "" {
+			fileKeywords, err := shared.ReadLines(keywordsFile)
+			if err != nil {
+				shared.Fatal(err)
+			}
+			keywords = append(keywords, fileKeywords...)
+		}
+
+		if len(keywords) == 0 {
+			shared.Fatal("[-] At least one keyword is required (--keywords or --keywords-file)")
+		}
+
+		candidates := shared.GenerateBucketNames(keywords)
+		fmt.Printf("[!] Probing %d candidate bucket names...\n", len(candidates))
+
+		wrapper := aws.InitializeS3Wrapper(ctx, region, "", true)
+
+		found := 0
+		wrapper.DiscoverBuckets(ctx, candidates, threads, func(result shared.BucketDiscoveryResult, err error) {
+			if err != nil {
+				log.Printf("[!] Failed to probe bucket %s: %v", result.Name, err)
+				return
+			}
+
+			if result.Status == shared.BucketNotFound {
+				if showMissing {
+					fmt.Printf("[-] %s: %s\n", result.Name, result.Status)
+				}
+				return
+			}
+
+			found++
+			fmt.Printf("[+] %s: %s (region: %s)\n", result.Name, result.Status, result.Region)
+		})
+
+		fmt.Printf("[!] Discovery finished, found %d existing buckets.\n", found)
+	},
+}
+
+var ProbePermissionsCmd = &cobra.Command{
+	Use:   "probe-permissions",
+	Short: "Test write/delete permissions on S3 buckets using a temporary marker object. This WRITES to the bucket.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if !allowWrites {
+			shared.Fatal("[-] This command writes to the target buckets, re-run with --i-understand-this-writes to continue")
+		}
+		if withScps && anonymousMode {
+			shared.Fatal("[-] --scps needs credentials, it can't be combined with --anonymous-mode")
+		}
+
+		var scps []shared.ScpLevel
+		partition := "aws"
+		if withScps {
+			scps, partition = callerScps()
+		}
+
+		wrapper := initializeS3Wrapper()
+
+		var bucketNames []string
+		if bucketName != "" {
+			bucketNames = append(bucketNames, bucketName)
+		} else {
+			fmt.Println("[!] No bucket specified, probing every bucket on the account...")
+			buckets, err := wrapper.ListBuckets(ctx)
+			if err != nil {
+				shared.Fatal("[-] Account does not have sufficient policies to list buckets (required policy action: s3:ListAllMyBuckets).")
+			}
+			for _, bucket := range buckets {
+				bucketNames = append(bucketNames, *bucket.Name)
+			}
+		}
+
+		var results []shared.BucketPermissions
+		for _, name := range bucketNames {
+			fmt.Printf("[!] Probing permissions on bucket %s...\n", name)
+			results = append(results, wrapper.ProbeBucketPermissions(ctx, name))
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "BUCKET\tPutObject\tPutObjectAcl\tGetBucketPolicy\tPutBucketPolicy\tDeleteObject\tDeleteObjectVersion")
+		var scpNotes []string
+		for _, result := range results {
+			bucketArn := "arn:" + partition + ":s3:::" + result.Bucket
+			checks := []struct {
+				result   shared.PermissionResult
+				action   string
+				resource string
+			}{
+				{result.PutObject, "s3:PutObject", bucketArn + "/*"},
+				{result.PutObjectAcl, "s3:PutObjectAcl", bucketArn + "/*"},
+				{result.GetBucketPolicy, "s3:GetBucketPolicy", bucketArn},
+				{result.PutBucketPolicy, "s3:PutBucketPolicy", bucketArn},
+				{result.DeleteObject, "s3:DeleteObject", bucketArn + "/*"},
+				{result.DeleteObjectVersion, "s3:DeleteObjectVersion", bucketArn + "/*"},
+			}
+
+			cells := []string{result.Bucket}
+			for _, check := range checks {
+				cell, note := scpPermission(check.result, scps, check.action, check.resource)
+				cells = append(cells, cell)
+				if note != "" {
+					scpNotes = append(scpNotes, fmt.Sprintf("[!] %s on %s: %s", check.action, result.Bucket, note))
+				}
+			}
+			fmt.Fprintln(writer, strings.Join(cells, "\t"))
+		}
+		writer.Flush()
+		for _, note := range scpNotes {
+			fmt.Println(note)
+		}
+		reportRequesterPays(wrapper)
+
+		for _, result := range results {
+			switch {
+			case len(result.MarkerVersionIds) > 0:
+				fmt.Printf("[!] Couldn't remove marker object, clean up manually: s3://%s/%s (versions %s)\n", result.Bucket, result.MarkerKey, strings.Join(result.MarkerVersionIds, ", "))
+			case result.MarkerKey != "":
+				fmt.Printf("[!] Couldn't remove marker object, clean up manually: s3://%s/%s\n", result.Bucket, result.MarkerKey)
+			}
+		}
+	},
+}
+
+var PresignCmd = &cobra.Command{
+	Use:   "presign",
+	Short: "Generate presigned GET URLs for a single object or every object matching a prefix/glob and write them to a manifest file",
+	Run: func(cmd *cobra.Command, args []string) {
+		if presignExpiry <= 0 || presignExpiry > aws.MaxPresignExpiry {
+			shared.Fatalf("[-] Expiry must be between 1s and %s", aws.MaxPresignExpiry)
+		}
+
+		wrapper := initializeS3Wrapper()
+
+		temporary, expires, err := wrapper.CredentialsExpiry(ctx)
+		if err != nil {
+			shared.Fatal(err)
+		}
+		// credentialsExpire is set when the credentials expire before the URLs would, which ends their lifetime early.
+		var credentialsExpire time.Time
+		if temporary {
+			fmt.Println("[!] Current credentials are temporary, presigned URLs stop working once they expire.")
+			if !expires.IsZero() && time.Until(expires) < presignExpiry {
+				fmt.Printf("[!] Credentials expire at %s, URL lifetime is capped to %s instead of %s.\n", expires.Format(time.DateTime), time.Until(expires).Round(time.Second), presignExpiry)
+				credentialsExpire = expires
+			}
+		}
+
+		var keys []string
+		if objectKey != "" {
+			keys = append(keys, objectKey)
+		} else {
+			fmt.Println("[!] Listing objects to presign...")
+			objects, err := wrapper.ListObjects(ctx, bucketName, listPrefix)
+			if err != nil {
+				shared.Fatal(err)
+			}
+			for _, object := range objects {
+				if keyGlob != "" {
+					if matched, _ := path.Match(keyGlob, *object.Key); !matched {
+						continue
+					}
+				}
+				keys = append(keys, *object.Key)
+			}
+		}
+
+		if len(keys) == 0 {
+			fmt.Println("[-] No objects matched, nothing to presign.")
+			return
+		}
+
+		type manifestEntry struct {
+			Bucket  string    `json:"bucket"`
+			Key     string    `json:"key"`
+			URL     string    `json:"url"`
+			Expires time.Time `json:"expires"`
+		}
+
+		var manifest []manifestEntry
+		for _, key := range keys {
+			url, err := wrapper.PresignGetObject(ctx, bucketName, key, presignExpiry)
+			if err != nil {
+				log.Printf("[!] Couldn't presign s3://%s/%s: %v", bucketName, key, err)
+				continue
+			}
+			expiresAt := time.Now().Add(presignExpiry)
+			if !credentialsExpire.IsZero() && credentialsExpire.Before(expiresAt) {
+				expiresAt = credentialsExpire
+			}
+			manifest = append(manifest, manifestEntry{
+				Bucket:  bucketName,
+				Key:     key,
+				URL:     url,
+				Expires: expiresAt.UTC(),
+			})
+		}
+
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			shared.Fatal(err)
+		}
+		if err := os.WriteFile(manifestFile, data, 0600); err != nil {
+			shared.Fatal(err)
+		}
+
+		reportRequesterPays(wrapper)
+		fmt.Printf("[+] Wrote %d presigned URLs to %s\n", len(manifest), manifestFile)
+	},
+}
+
+var CatObjectCmd = &cobra.Command{
+	Use:   "cat",
+	Short: "Stream a single object (or a byte range of it) to stdout, decompressing and pretty-printing it when possible",
+	Run: func(cmd *cobra.Command, args []string) {
+		rangeHeader := byteRange
+		if rangeHeader != "" && !strings.HasPrefix(rangeHeader, "bytes=") {
+			rangeHeader = "bytes=" + rangeHeader
+		}
+
+		printObject(rangeHeader)
+	},
+}
+
+var HeadBytesCmd = &cobra.Command{
+	Use:   "head-bytes",
+	Short: "Print the first bytes of a single object using a ranged GET",
+	Run: func(cmd *cobra.Command, args []string) {
+		if headBytes <= 0 {
+			shared.Fatal("[-] Number of bytes must be positive")
+		}
+
+		printObject(fmt.Sprintf("bytes=0-%d", headBytes-1))
+	},
+}
+
+func printObject(rangeHeader string) {
+	if maxOutput <= 0 {
+		shared.Fatal("[-] --max-output must be positive")
+	}
+
+	wrapper := initializeS3Wrapper()
+
+	body, err := wrapper.GetObjectStream(ctx, bucketName, objectKey, rangeHeader)
+	if err != nil {
+		shared.Fatal(err)
+	}
+	defer body.Close()
+
+	if err := shared.WriteObjectContent(os.Stdout, body, objectKey, rawOutput, maxOutput); err != nil {
+		shared.Fatal(err)
+	}
+}
+
+var SnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save the listing of a bucket (keys, sizes, ETags, last modified dates) to a file for later comparison",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Taking snapshot of the bucket...")
+
+		wrapper := initializeS3Wrapper()
+
+		snapshot, err := wrapper.TakeSnapshot(ctx, bucketName)
+		if err != nil {
+			shared.Fatal(err)
+		}
+
+		output := snapshotFile
+		if output == "" {
+			output = fmt.Sprintf("%s-%s.json", bucketName, snapshot.TakenAt.Format("20060102-150405"))
+		}
+
+		if err := shared.SaveSnapshot(output, snapshot); err != nil {
+			shared.Fatal(err)
+		}
+
+		reportRequesterPays(wrapper)
+		fmt.Printf("[+] Saved snapshot of %d objects to %s\n", len(snapshot.Objects), output)
+	},
+}
+
+var DiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare a bucket snapshot with another snapshot or with the live bucket and report added, removed and modified objects",
+	Run: func(cmd *cobra.Command, args []string) {
+		older, err := shared.LoadSnapshot(olderSnapshotFile)
+		if err != nil {
+			shared.Fatal(err)
+		}
+
+		var newer shared.BucketSnapshot
+		if newerSnapshotFile != "" {
+			newer, err = shared.LoadSnapshot(newerSnapshotFile)
+			if err != nil {
+				shared.Fatal(err)
+			}
+		}
+
+		if bucketName == "" {
+			bucketName = older.Bucket
+		}
+
+		var wrapper aws.S3Wrapper
+		if newerSnapshotFile == "" || fetchChanged {
+			wrapper = initializeS3Wrapper()
+		}
+
+		if newerSnapshotFile == "" {
+			fmt.Printf("[!] Comparing snapshot from %s with live bucket %s...\n", older.TakenAt.Format(time.DateTime), bucketName)
+			newer, err = wrapper.TakeSnapshot(ctx, bucketName)
+			if err != nil {
+				shared.Fatal(err)
+			}
+		}
+
+		diff := shared.DiffSnapshots(older, newer)
+
+		for _, object := range diff.Added {
+			fmt.Printf("[+] Added: %s (%s, %s)\n", object.Key, shared.FormatBytes(object.Size), object.LastModified.Format(time.DateTime))
+		}
+		for _, object := range diff.Modified {
+			fmt.Printf("[~] Modified: %s (%s, %s)\n", object.Key, shared.FormatBytes(object.Size), object.LastModified.Format(time.DateTime))
+		}
+		for _, object := range diff.Removed {
+			fmt.Printf("[-] Removed: %s\n", object.Key)
+		}
+		fmt.Printf("[!] %d added, %d modified, %d removed\n", len(diff.Added), len(diff.Modified), len(diff.Removed))
+
+		if fetchChanged {
+			for _, object := range diff.Changed() {
+				if err := wrapper.DownloadObject(ctx, bucketName, object.Key, localFolder); err != nil {
+					log.Printf("[!] Couldn't download %s: %v", object.Key, err)
+				}
+			}
+			fmt.Printf("[+] Downloaded changed objects to local folder: %s\n", localFolder)
+		}
+
+		if newerSnapshotFile == "" || fetchChanged {
+			reportRequesterPays(wrapper)
+		}
+	},
+}
+
+var AccessPointsCmd = &cobra.Command{
+	Use:   "access-points",
+	Short: "Enumerate S3 access points, Object Lambda access points and Multi-Region Access Points with their policies",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Enumerating S3 access points...")
+
+		wrapper := aws.InitializeS3ControlWrapper(ctx, region, profile)
+
+		var accessPoints []shared.AccessPoint
+		for _, scanRegion := range shared.RegionsToScan(region, allRegions) {
+			found, err := wrapper.ListAccessPointsWrapper(ctx, scanRegion)
+			if err != nil {
+				log.Printf("[!] Couldn't list access points in %s: %v", scanRegion, err)
+			}
+			accessPoints = append(accessPoints, found...)
+
+			found, err = wrapper.ListObjectLambdaAccessPointsWrapper(ctx, scanRegion)
+			if err != nil {
+				log.Printf("[!] Couldn't list Object Lambda access points in %s: %v", scanRegion, err)
+			}
+			accessPoints = append(accessPoints, found...)
+		}
+
+		found, err := wrapper.ListMultiRegionAccessPointsWrapper(ctx)
+		if err != nil {
+			log.Printf("[!] Couldn't list Multi-Region Access Points: %v", err)
+		}
+		accessPoints = append(accessPoints, found...)
+
+		if len(accessPoints) == 0 {
+			fmt.Println("[-] No access points found on the account!")
+			return
+		}
+
+		for _, accessPoint := range accessPoints {
+			fmt.Printf("[+] Found %s!\n Name: %s\n ARN: %s\n", accessPoint.Kind, accessPoint.Name, accessPoint.Arn)
+			if accessPoint.Alias != "" {
+				fmt.Printf(" Alias: %s\n", accessPoint.Alias)
+			}
+			if accessPoint.Bucket != "" {
+				fmt.Printf(" Bucket: %s\n", accessPoint.Bucket)
+			}
+			fmt.Printf(" Region: %s\n", accessPoint.Region)
+			if accessPoint.NetworkOrigin != "" {
+				fmt.Printf(" Network origin: %s", accessPoint.NetworkOrigin)
+				if accessPoint.VpcId != "" {
+					fmt.Printf(" (%s)", accessPoint.VpcId)
+				}
+				fmt.Println()
+			}
+			fmt.Printf(" Policy:\n%s\n\n", accessPoint.Policy)
+		}
+
+		fmt.Println("[!] Access point ARNs can be passed to list-content and dump-bucket via --bucket-name.")
+	},
+}
+
+// maxStateFileSize keeps the harvester from pulling huge objects that merely share a suffix.
+const maxStateFileSize = 50 * 1024 * 1024
+
+var TfStateCmd = &cobra.Command{
+	Use:   "tfstate",
+	Short: "Locate Terraform state files and CloudFormation templates in accessible buckets and extract secrets, outputs and ARNs",
+	Run: func(cmd *cobra.Command, args []string) {
+		wrapper := initializeS3Wrapper()
+
+		var bucketNames []string
+		if bucketName != "" {
+			bucketNames = append(bucketNames, bucketName)
+		} else {
+			fmt.Println("[!] No bucket specified, searching every bucket on the account...")
+			buckets, err := wrapper.ListBuckets(ctx)
+			if err != nil {
+				shared.Fatal("[-] Account does not have sufficient policies to list buckets (required policy action: s3:ListAllMyBuckets).")
+			}
+			for _, bucket := range buckets {
+				bucketNames = append(bucketNames, *bucket.Name)
+			}
+		}
+
+		var reports []shared.StateReport
+		var allArns []string
+		for _, name := range bucketNames {
+			fmt.Printf("[!] Searching bucket %s for state files and templates...\n", name)
+
+			// Only the matching keys are kept, buckets can hold millions of objects.
+			var stateKeys []string
+			err := wrapper.WalkObjects(ctx, name, "", func(objects []types.Object) {
+				for _, object := range objects {
+					if _, ok := shared.StateFileKind(*object.Key); ok {
+						stateKeys = append(stateKeys, *object.Key)
+					}
+				}
+			})
+			if err != nil {
+				log.Printf("[!] Couldn't list bucket %s: %v", name, err)
+			}
+
+			for _, key := range stateKeys {
+				kind, _ := shared.StateFileKind(key)
+
+				data, err := wrapper.ReadObject(ctx, name, key, maxStateFileSize)
+				if err != nil {
+					log.Printf("[!] Couldn't read s3://%s/%s: %v", name, key, err)
+					continue
+				}
+
+				var report shared.StateReport
+				if kind == shared.StateKindTerraform {
+					report, err = shared.ParseTerraformState(data)
+				} else {
+					report, err = shared.ParseCloudFormationTemplate(data)
+				}
+				if err != nil {
+					log.Printf("[!] Skipping s3://%s/%s: %v", name, key, err)
+					continue
+				}
+				report.Source = fmt.Sprintf("s3://%s/%s", name, key)
+
+				printStateReport(report)
+				reports = append(reports, report)
+				allArns = append(allArns, report.Arns...)
+			}
+		}
+
+		reportRequesterPays(wrapper)
+
+		if len(reports) == 0 {
+			fmt.Println("[-] No Terraform state files or CloudFormation templates found.")
+			return
+		}
+
+		seeds := shared.SeedsFromArns(allArns)
+		fmt.Println("[+] Seeds for further enumeration:")
+		fmt.Printf(" Accounts: %s\n Roles (iam get-role -n): %s\n Users (iam get-user -u): %s\n Buckets (s3 list-content -b): %s\n",
+			strings.Join(seeds.Accounts, ", "), strings.Join(seeds.Roles, ", "), strings.Join(seeds.Users, ", "), strings.Join(seeds.Buckets, ", "))
+
+		data, err := json.MarshalIndent(struct {
+			Reports []shared.StateReport    `json:"reports"`
+			Seeds   shared.EnumerationSeeds `json:"seeds"`
+		}{reports, seeds}, "", "  ")
+		if err != nil {
+			shared.Fatal(err)
+		}
+		if err := os.WriteFile(reportFile, data, 0600); err != nil {
+			shared.Fatal(err)
+		}
+
+		fmt.Printf("[+] Wrote report for %d files to %s\n", len(reports), reportFile)
+	},
+}
+
+func printStateReport(report shared.StateReport) {
+	fmt.Printf("[+] Found %s file: %s\n Resources: %d\n ARNs: %d\n", report.Kind, report.Source, len(report.Resources), len(report.Arns))
+
+	for _, output := range report.Outputs {
+		if output.Sensitive {
+			fmt.Printf(" Output (sensitive): %s = %s\n", output.Name, output.Value)
+		}
+	}
+	for _, credential := range report.Credentials {
+		fmt.Printf(" Credential: %s.%s = %s\n", credential.Resource, credential.Attribute, credential.Value)
+	}
+	for _, secret := range report.Sensitive {
+		fmt.Printf(" Sensitive: %s.%s = %s\n", secret.Resource, secret.Attribute, secret.Value)
+	}
+}
+
+// initializeS3Wrapper builds the wrapper from the flags shared by the bucket level commands.
+// callerScps collects the SCPs that apply to the caller's account together with the caller's
+// partition, which the bucket ARNs they are evaluated against are built in. Failing to read
+// them is logged and leaves the probe results as they are.
+func callerScps() ([]shared.ScpLevel, string) {
+	identity, err := aws.InitializeStsWrapper(ctx, region, profile).GetCallerIdentityWrapper(ctx)
+	if err != nil {
+		shared.Fatal(err)
+	}
+	caller, err := arn.Parse(awssdk.ToString(identity.Arn))
+	if err != nil {
+		shared.Fatal(err)
+	}
+
+	levels, err := aws.InitializeOrganizationsWrapper(ctx, region, profile).AccountServiceControlPoliciesWrapper(ctx, caller.AccountID)
+	if err != nil {
+		log.Printf("[!] Couldn't collect SCPs of account %s, ignoring them: %v", caller.AccountID, err)
+	}
+
+	return levels, caller.Partition
+}
+
+// scpPermission checks an allowed probe result against the SCPs. Actions an SCP blocks are
+// reported as blocked instead of allowed, and the returned note explains the SCP decision.
+func scpPermission(result shared.PermissionResult, scps []shared.ScpLevel, action string, resource string) (string, string) {
+	if result != shared.PermissionAllowed {
+		return result.String(), ""
+	}
+
+	switch decision, reason := shared.EvaluateScps(scps, action, resource); decision {
+	case shared.PolicyAllow:
+		return result.String(), ""
+	case shared.PolicyConditional:
+		return "conditionally blocked", "conditionally blocked at the org level (" + reason + ")"
+	default:
+		return "blocked by SCP", "blocked at the org level (" + reason + ")"
+	}
+}
+
+func initializeS3Wrapper() aws.S3Wrapper {
+	wrapper := aws.InitializeS3Wrapper(ctx, region, profile, anonymousMode)
+	wrapper.RequesterPays = requesterPays
+	wrapper.ExpectedOwner = expectedOwner
+
+	return wrapper
+}
+
+// reportRequesterPays lists the buckets that only worked after retrying as requester-pays.
+func reportRequesterPays(wrapper aws.S3Wrapper) {
+	for _, bucket := range wrapper.RequesterPaysBuckets() {
+		fmt.Printf("[!] Bucket %s is requester-pays, requests were retried with RequestPayer=requester\n", bucket)
+	}
+}
+
+func addBucketAccessFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&requesterPays, "requester-pays", false, "Send RequestPayer=requester with every bucket request")
+	cmd.Flags().StringVar(&expectedOwner, "expected-owner", "", "Account ID expected to own the bucket (x-amz-expected-bucket-owner)")
+}
+
 func init() {
 	ListBucketContentCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
 	ListBucketContentCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
-	ListBucketContentCmd.Flags().StringVarP(&bucketName, "bucket-name", "b", "", "Name of S3 bucket")
+	ListBucketContentCmd.Flags().StringVarP(&bucketName, "bucket-name", "b", "", "Name of S3 bucket or access point ARN")
 	ListBucketContentCmd.Flags().BoolVarP(&anonymousMode, "anonymous-mode", "a", false, "Use anonymous authentication")
+	ListBucketContentCmd.Flags().BoolVarP(&longListing, "long", "l", false, "Show size, last modified date, storage class, owner and ETag of objects, and the total size of every folder")
+	ListBucketContentCmd.Flags().BoolVarP(&withMetadata, "with-metadata", "m", false, "Retrieve user metadata, encryption, object lock status and tags of every object (implies --long and --tree)")
+	ListBucketContentCmd.Flags().BoolVar(&treeView, "tree", false, "Render the content as a tree once listing finishes instead of streaming it")
+	ListBucketContentCmd.Flags().StringVar(&listPrefix, "prefix", "", "Only list keys below this prefix")
+	ListBucketContentCmd.Flags().IntVar(&maxDepth, "max-depth", 0, "Maximum folder depth to descend into, 1 lists only the top level (0 = unlimited)")
+	ListBucketContentCmd.Flags().IntVar(&maxObjects, "max-objects", 0, "Stop after this many folders and objects (0 = unlimited)")
+	ListBucketContentCmd.Flags().IntVarP(&listWorkers, "threads", "t", 5, "Number of folders listed concurrently")
 
 	ListBucketsCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
 	ListBucketsCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
 
 	DumpBucketCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
 	DumpBucketCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
-	DumpBucketCmd.Flags().StringVarP(&bucketName, "bucket-name", "b", "", "Name of S3 bucket")
+	DumpBucketCmd.Flags().StringVarP(&bucketName, "bucket-name", "b", "", "Name of S3 bucket or access point ARN")
 	DumpBucketCmd.Flags().BoolVarP(&anonymousMode, "anonymous-mode", "a", false, "Use anonymous authentication")
 	DumpBucketCmd.Flags().StringVarP(&localFolder, "folder", "f", "bucket", "Local folder used to store the bucket content")
+
+	DiscoverBucketsCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region used for the initial requests")
+	DiscoverBucketsCmd.Flags().StringSliceVarP(&keywords, "keywords", "k", nil, "Comma separated list of company keywords")
+	DiscoverBucketsCmd.Flags().StringVarP(&keywordsFile, "keywords-file", "w", "", "File containing one keyword per line")
+	DiscoverBucketsCmd.Flags().IntVarP(&threads, "threads", "t", 10, "Number of concurrent workers")
+	DiscoverBucketsCmd.Flags().BoolVar(&showMissing, "show-missing", false, "Also print candidates that do not exist")
+
+	ProbePermissionsCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	ProbePermissionsCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	ProbePermissionsCmd.Flags().StringVarP(&bucketName, "bucket-name", "b", "", "Name of S3 bucket (defaults to every bucket on the account)")
+	ProbePermissionsCmd.Flags().BoolVarP(&anonymousMode, "anonymous-mode", "a", false, "Use anonymous authentication")
+	ProbePermissionsCmd.Flags().BoolVar(&allowWrites, "i-understand-this-writes", false, "Confirm that marker objects may be written to and deleted from the buckets")
+	ProbePermissionsCmd.Flags().BoolVar(&withScps, "scps", false, "Also evaluate the organization's SCPs, needs Organizations read access (SCP conditions aren't evaluated)")
+
+	PresignCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	PresignCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	PresignCmd.Flags().StringVarP(&bucketName, "bucket-name", "b", "", "Name of S3 bucket")
+	PresignCmd.Flags().StringVarP(&objectKey, "key", "k", "", "Key of a single object to presign")
+	PresignCmd.Flags().StringVar(&listPrefix, "prefix", "", "Presign every object below this prefix")
+	PresignCmd.Flags().StringVarP(&keyGlob, "glob", "g", "", "Only presign keys matching this glob, e.g. 'backups/*.sql'")
+	PresignCmd.Flags().DurationVarP(&presignExpiry, "expiry", "e", time.Hour, "Lifetime of the presigned URLs (max 168h)")
+	PresignCmd.Flags().StringVarP(&manifestFile, "output", "o", "presigned-urls.json", "Manifest file the URLs are written to")
+
+	addBucketAccessFlags(ListBucketContentCmd)
+	addBucketAccessFlags(DumpBucketCmd)
+	addBucketAccessFlags(ProbePermissionsCmd)
+	addBucketAccessFlags(PresignCmd)
+
+	CatObjectCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	CatObjectCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	CatObjectCmd.Flags().StringVarP(&bucketName, "bucket-name", "b", "", "Name of S3 bucket")
+	CatObjectCmd.Flags().StringVarP(&objectKey, "key", "k", "", "Key of the object")
+	CatObjectCmd.Flags().BoolVarP(&anonymousMode, "anonymous-mode", "a", false, "Use anonymous authentication")
+	CatObjectCmd.Flags().StringVar(&byteRange, "range", "", "Byte range to fetch, e.g. 100-199")
+	CatObjectCmd.Flags().BoolVar(&rawOutput, "raw", false, "Print the object bytes unmodified, even if binary")
+	CatObjectCmd.Flags().Int64Var(&maxOutput, "max-output", 64<<20, "Maximum number of bytes to print after decompression")
+	addBucketAccessFlags(CatObjectCmd)
+
+	HeadBytesCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	HeadBytesCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	HeadBytesCmd.Flags().StringVarP(&bucketName, "bucket-name", "b", "", "Name of S3 bucket")
+	HeadBytesCmd.Flags().StringVarP(&objectKey, "key", "k", "", "Key of the object")
+	HeadBytesCmd.Flags().BoolVarP(&anonymousMode, "anonymous-mode", "a", false, "Use anonymous authentication")
+	HeadBytesCmd.Flags().Int64VarP(&headBytes, "bytes", "n", 1024, "Number of bytes to fetch")
+	HeadBytesCmd.Flags().BoolVar(&rawOutput, "raw", false, "Print the object bytes unmodified, even if binary")
+	HeadBytesCmd.Flags().Int64Var(&maxOutput, "max-output", 64<<20, "Maximum number of bytes to print after decompression")
+	addBucketAccessFlags(HeadBytesCmd)
+
+	SnapshotCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	SnapshotCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	SnapshotCmd.Flags().StringVarP(&bucketName, "bucket-name", "b", "", "Name of S3 bucket")
+	SnapshotCmd.Flags().BoolVarP(&anonymousMode, "anonymous-mode", "a", false, "Use anonymous authentication")
+	SnapshotCmd.Flags().StringVarP(&snapshotFile, "output", "o", "", "Snapshot file (defaults to <bucket>-<timestamp>.json)")
+	addBucketAccessFlags(SnapshotCmd)
+
+	DiffCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	DiffCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	DiffCmd.Flags().StringVarP(&bucketName, "bucket-name", "b", "", "Name of S3 bucket (defaults to the bucket of the older snapshot)")
+	DiffCmd.Flags().BoolVarP(&anonymousMode, "anonymous-mode", "a", false, "Use anonymous authentication")
+	DiffCmd.Flags().StringVar(&olderSnapshotFile, "old", "", "Older snapshot file")
+	DiffCmd.Flags().StringVar(&newerSnapshotFile, "new", "", "Newer snapshot file (defaults to the live bucket)")
+	DiffCmd.Flags().BoolVar(&fetchChanged, "fetch-changed", false, "Download added and modified objects")
+	DiffCmd.Flags().StringVarP(&localFolder, "folder", "f", "bucket", "Local folder used to store changed objects")
+	DiffCmd.MarkFlagRequired("old")
+	addBucketAccessFlags(DiffCmd)
+
+	AccessPointsCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	AccessPointsCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	AccessPointsCmd.Flags().BoolVar(&allRegions, "all-regions", false, "Enumerate access points in every region")
+
+	TfStateCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	TfStateCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	TfStateCmd.Flags().StringVarP(&bucketName, "bucket-name", "b", "", "Name of S3 bucket (defaults to every bucket on the account)")
+	TfStateCmd.Flags().BoolVarP(&anonymousMode, "anonymous-mode", "a", false, "Use anonymous authentication")
+	TfStateCmd.Flags().StringVarP(&reportFile, "output", "o", "tfstate-report.json", "File the structured report is written to")
+	addBucketAccessFlags(TfStateCmd)
 }
@@ -11,4 +11,13 @@ func init() {
 	S3Cmd.AddCommand(ListBucketContentCmd)
 	S3Cmd.AddCommand(ListBucketsCmd)
 	S3Cmd.AddCommand(DumpBucketCmd)
+	S3Cmd.AddCommand(DiscoverBucketsCmd)
+	S3Cmd.AddCommand(ProbePermissionsCmd)
+	S3Cmd.AddCommand(PresignCmd)
+	S3Cmd.AddCommand(CatObjectCmd)
+	S3Cmd.AddCommand(HeadBytesCmd)
+	S3Cmd.AddCommand(SnapshotCmd)
+	S3Cmd.AddCommand(DiffCmd)
+	S3Cmd.AddCommand(AccessPointsCmd)
+	S3Cmd.AddCommand(TfStateCmd)
 }
@@ -0,0 +1,121 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Kimi99/cloudhunter/internal/aws"
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/spf13/cobra"
+)
+
+var region string
+var profile string
+var allRegions bool
+var fetchValues bool
+var redact bool
+var ctx = context.TODO()
+
+var EnumSecretsCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Retrieve secrets with their rotation settings and resource policies, optionally with their values",
+	Long:  "Retrieve Secrets Manager secrets with their KMS key, rotation settings, last access and resource policy. With --fetch-values every version of each secret is retrieved, including previous versions. JSON values are broken down by key, and --redact keeps only the key names.",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Starting Secrets Manager enumeration...")
+
+		for _, scanRegion := range shared.RegionsToScan(region, allRegions) {
+			if allRegions {
+				fmt.Printf("[!] Region: %s\n", scanRegion)
+			}
+
+			wrapper := aws.InitializeSecretsManagerWrapper(ctx, scanRegion, profile)
+			secrets, err := wrapper.ListSecretsWrapper(ctx)
+			if err != nil {
+				if !allRegions {
+					shared.Fatal(err)
+				}
+				log.Printf("[!] Skipping region %s: %v", scanRegion, err)
+				continue
+			}
+
+			for _, secret := range secrets {
+				printSecret(wrapper, secret)
+			}
+		}
+	},
+}
+
+func printSecret(wrapper aws.SecretsManagerWrapper, secret types.SecretListEntry) {
+	secretId := awssdk.ToString(secret.ARN)
+
+	kmsKey := awssdk.ToString(secret.KmsKeyId)
+	if kmsKey == "" {
+		kmsKey = "aws/secretsmanager (AWS managed)"
+	}
+
+	fmt.Printf("[+] Found secret!\n Name: %s\n ARN: %s\n Description: %s\n KMS key: %s\n Rotation enabled: %t\n",
+		awssdk.ToString(secret.Name), secretId, awssdk.ToString(secret.Description), kmsKey, awssdk.ToBool(secret.RotationEnabled))
+	if secret.RotationLambdaARN != nil {
+		fmt.Printf(" Rotation function: %s\n", awssdk.ToString(secret.RotationLambdaARN))
+	}
+	fmt.Printf(" Last rotated: %v\n Last changed: %v\n Last accessed: %v\n",
+		awssdk.ToTime(secret.LastRotatedDate), awssdk.ToTime(secret.LastChangedDate), awssdk.ToTime(secret.LastAccessedDate))
+
+	policy, err := wrapper.GetResourcePolicyWrapper(ctx, secretId)
+	if err != nil {
+		log.Printf("[!] Couldn't retrieve resource policy of %s: %v", secretId, err)
+	} else if policy != "" {
+		fmt.Printf(" Resource policy:\n%s\n", shared.IndentedOrRaw(policy, "resource policy of "+secretId))
+	}
+
+	if fetchValues {
+		printSecretVersions(wrapper, secretId)
+	}
+
+	fmt.Println()
+}
+
+func printSecretVersions(wrapper aws.SecretsManagerWrapper, secretId string) {
+	versions, err := wrapper.ListSecretVersionsWrapper(ctx, secretId)
+	if err != nil {
+		log.Printf("[!] Couldn't list versions of %s: %v", secretId, err)
+		return
+	}
+
+	for _, version := range versions {
+		versionId := awssdk.ToString(version.VersionId)
+		stages := "deprecated"
+		if len(version.VersionStages) > 0 {
+			stages = strings.Join(version.VersionStages, ", ")
+		}
+
+		value, err := wrapper.GetSecretValueWrapper(ctx, secretId, versionId)
+		if err != nil {
+			fmt.Printf(" Version %s (%s): couldn't retrieve value: %v\n", versionId, stages, err)
+			continue
+		}
+
+		fmt.Printf(" Version %s (%s) created %v:\n", versionId, stages, awssdk.ToTime(version.CreatedDate))
+		switch {
+		case value.SecretString != nil:
+			fmt.Printf("  Value: %s\n", shared.FormatSecretValue(*value.SecretString, redact))
+		case redact:
+			fmt.Printf("  Value: <redacted binary, %d bytes>\n", len(value.SecretBinary))
+		default:
+			fmt.Printf("  Value (base64): %s\n", base64.StdEncoding.EncodeToString(value.SecretBinary))
+		}
+	}
+}
+
+func init() {
+	EnumSecretsCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumSecretsCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	EnumSecretsCmd.Flags().BoolVar(&allRegions, "all-regions", false, "Enumerate every region")
+	EnumSecretsCmd.Flags().BoolVar(&fetchValues, "fetch-values", false, "Retrieve the value of every version of each secret")
+	EnumSecretsCmd.Flags().BoolVar(&redact, "redact", false, "Hide secret values, keeping only the key names of JSON secrets")
+}
@@ -0,0 +1,12 @@
+package secrets
+
+import "github.com/spf13/cobra"
+
+var SecretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Interact with AWS Secrets Manager service",
+}
+
+func init() {
+	SecretsCmd.AddCommand(EnumSecretsCmd)
+}
@@ -0,0 +1,277 @@
+package ssm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Kimi99/cloudhunter/internal/aws"
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/spf13/cobra"
+)
+
+var region string
+var profile string
+var allRegions bool
+var decrypt bool
+var sharedOnly bool
+var withScps bool
+var ctx = context.TODO()
+
+// lateralMovementActions are the SSM actions that give a shell or command execution on a managed instance.
+var lateralMovementActions = []string{"ssm:SendCommand", "ssm:StartSession"}
+
+var EnumParametersCmd = &cobra.Command{
+	Use:   "parameters",
+	Short: "Retrieve Parameter Store parameters without their values",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Starting Parameter Store enumeration...")
+
+		forEachRegion(func(wrapper aws.SsmWrapper, scanRegion string) error {
+			parameters, err := wrapper.DescribeParametersWrapper(ctx)
+			if err != nil {
+				return err
+			}
+
+			for _, parameter := range parameters {
+				marker := ""
+				if parameter.Type == types.ParameterTypeSecureString || shared.IsSecretName(awssdk.ToString(parameter.Name)) {
+					marker = " [POSSIBLE SECRET]"
+				}
+
+				fmt.Printf("[+] Found parameter!%s\n Name: %s\n Type: %s\n Tier: %s\n KMS key: %s\n Description: %s\n Last modified: %v by %s\n Version: %d\n\n",
+					marker, awssdk.ToString(parameter.Name), parameter.Type, parameter.Tier, awssdk.ToString(parameter.KeyId), awssdk.ToString(parameter.Description),
+					awssdk.ToTime(parameter.LastModifiedDate), awssdk.ToString(parameter.LastModifiedUser), parameter.Version)
+			}
+			return nil
+		})
+	},
+}
+
+var GetParametersCmd = &cobra.Command{
+	Use:   "get-parameters [path]",
+	Short: "Retrieve the values of every parameter below a path, decrypting SecureStrings with --decrypt",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := "/"
+		if len(args) == 1 {
+			path = args[0]
+		}
+
+		fmt.Printf("[!] Retrieving parameters below %s...\n", path)
+
+		forEachRegion(func(wrapper aws.SsmWrapper, scanRegion string) error {
+			parameters, err := wrapper.GetParametersByPathWrapper(ctx, path, decrypt)
+			if err != nil {
+				return err
+			}
+
+			// Names without a leading "/", such as db_password, aren't part of any path
+			// hierarchy, so GetParametersByPath never returns them even for "/".
+			if path == "/" {
+				unrooted, err := getUnrootedParameters(wrapper)
+				if err != nil {
+					log.Printf("[!] Couldn't retrieve parameters without a leading / in %s: %v", scanRegion, err)
+				}
+				parameters = append(parameters, unrooted...)
+			}
+
+			for _, parameter := range parameters {
+				value := awssdk.ToString(parameter.Value)
+				marker := ""
+				if shared.IsSecretName(awssdk.ToString(parameter.Name)) || len(shared.DetectSecrets(value)) > 0 {
+					marker = " [POSSIBLE SECRET]"
+				}
+				if parameter.Type == types.ParameterTypeSecureString && !decrypt {
+					value = "<encrypted, use --decrypt>"
+				}
+
+				fmt.Printf("[+] %s (%s, version %d)%s\n %s\n\n", awssdk.ToString(parameter.Name), parameter.Type, parameter.Version, marker, value)
+			}
+			return nil
+		})
+	},
+}
+
+// getUnrootedParameters retrieves the values of the parameters whose name doesn't start with "/".
+func getUnrootedParameters(wrapper aws.SsmWrapper) ([]types.Parameter, error) {
+	metadata, err := wrapper.DescribeParametersWrapper(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, parameter := range metadata {
+		if name := awssdk.ToString(parameter.Name); !strings.HasPrefix(name, "/") {
+			names = append(names, name)
+		}
+	}
+
+	return wrapper.GetParametersWrapper(ctx, names, decrypt)
+}
+
+var EnumManagedInstancesCmd = &cobra.Command{
+	Use:   "instances",
+	Short: "Retrieve SSM managed instances and check whether the caller can run commands or start sessions on them",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Starting SSM managed instance enumeration...")
+
+		identity, err := aws.InitializeStsWrapper(ctx, region, profile).GetCallerIdentityWrapper(ctx)
+		if err != nil {
+			shared.Fatal(err)
+		}
+
+		caller, err := arn.Parse(awssdk.ToString(identity.Arn))
+		if err != nil {
+			shared.Fatal(err)
+		}
+
+		iamWrapper := aws.InitializeIamWrapper(ctx, region, profile)
+		principalArn, err := iamWrapper.CallerPrincipalArnWrapper(ctx, awssdk.ToString(identity.Arn))
+		if err != nil {
+			shared.Fatal(err)
+		}
+
+		var scps []shared.ScpLevel
+		if withScps {
+			scps, err = aws.InitializeOrganizationsWrapper(ctx, region, profile).AccountServiceControlPoliciesWrapper(ctx, caller.AccountID)
+			if err != nil {
+				log.Printf("[!] Couldn't collect SCPs of account %s, ignoring them: %v", caller.AccountID, err)
+			}
+		}
+
+		forEachRegion(func(wrapper aws.SsmWrapper, scanRegion string) error {
+			instances, err := wrapper.DescribeInstanceInformationWrapper(ctx)
+			if err != nil {
+				return err
+			}
+
+			for _, instance := range instances {
+				instanceId := awssdk.ToString(instance.InstanceId)
+				resourceArn := managedInstanceArn(caller, instanceId, wrapper.SsmClient.Options().Region)
+
+				access := "none"
+				allowed, err := iamWrapper.SimulatePrincipalPolicyWrapper(ctx, principalArn, lateralMovementActions, []string{resourceArn})
+				if err != nil {
+					access = fmt.Sprintf("unknown (%v)", err)
+				} else if len(allowed) > 0 {
+					access = lateralMovementAccess(allowed, scps, resourceArn)
+				}
+
+				fmt.Printf("[+] Found managed instance!\n Instance ID: %s\n Computer name: %s\n IP address: %s\n Platform: %s %s\n Ping status: %s\n Agent version: %s\n IAM role: %s\n Caller can: %s\n\n",
+					instanceId, awssdk.ToString(instance.ComputerName), awssdk.ToString(instance.IPAddress), awssdk.ToString(instance.PlatformName), awssdk.ToString(instance.PlatformVersion),
+					instance.PingStatus, awssdk.ToString(instance.AgentVersion), awssdk.ToString(instance.IamRole), access)
+			}
+			return nil
+		})
+	},
+}
+
+var EnumDocumentsCmd = &cobra.Command{
+	Use:   "documents",
+	Short: "Retrieve SSM documents owned by the account and flag public or cross-account sharing",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("[!] Retrieving SSM documents...")
+
+		forEachRegion(func(wrapper aws.SsmWrapper, scanRegion string) error {
+			documents, err := wrapper.ListOwnedDocumentsWrapper(ctx)
+			if err != nil {
+				return err
+			}
+
+			for _, document := range documents {
+				// Failures are printed as unknown sharing.
+				sharing, _ := wrapper.GetDocumentSharingWrapper(ctx, awssdk.ToString(document.Name))
+				if sharedOnly && !sharing.MayBeShared() {
+					continue
+				}
+
+				fmt.Printf("[+] Found document!\n Name: %s\n Type: %s\n Format: %s\n Version: %s\n Created: %v\n Shared with: %s\n\n",
+					awssdk.ToString(document.Name), document.DocumentType, document.DocumentFormat, awssdk.ToString(document.DocumentVersion), awssdk.ToTime(document.CreatedDate), sharing)
+			}
+			return nil
+		})
+	},
+}
+
+// lateralMovementAccess describes the actions IAM allows on the instance, after checking them
+// against the SCPs of the caller's account. Actions an SCP blocks don't count as lateral movement.
+func lateralMovementAccess(allowed []string, scps []shared.ScpLevel, resourceArn string) string {
+	var usable, blocked []string
+	for _, action := range allowed {
+		switch decision, reason := shared.EvaluateScps(scps, action, resourceArn); decision {
+		case shared.PolicyAllow:
+			usable = append(usable, action)
+		case shared.PolicyConditional:
+			usable = append(usable, action+" (conditionally blocked at the org level: "+reason+")")
+		default:
+			blocked = append(blocked, action+" (blocked at the org level: "+reason+")")
+		}
+	}
+
+	if len(usable) == 0 {
+		return "none, " + strings.Join(blocked, ", ")
+	}
+
+	access := strings.Join(usable, ", ") + " [LATERAL MOVEMENT]"
+	if len(blocked) > 0 {
+		access += "; " + strings.Join(blocked, ", ")
+	}
+
+	return access
+}
+
+// forEachRegion runs fn against a wrapper for every region selected by the --region and
+// --all-regions flags. Failures in one region are logged and don't stop the others.
+func forEachRegion(fn func(wrapper aws.SsmWrapper, scanRegion string) error) {
+	for _, scanRegion := range shared.RegionsToScan(region, allRegions) {
+		if allRegions {
+			fmt.Printf("[!] Region: %s\n", scanRegion)
+		}
+
+		wrapper := aws.InitializeSsmWrapper(ctx, scanRegion, profile)
+		if err := fn(wrapper, scanRegion); err != nil {
+			if !allRegions {
+				shared.Fatal(err)
+			}
+			log.Printf("[!] Skipping region %s: %v", scanRegion, err)
+		}
+	}
+}
+
+// managedInstanceArn returns the ARN SSM authorizes against: EC2 instances are addressed as
+// ec2 instances, hybrid nodes (mi- IDs) as SSM managed instances.
+func managedInstanceArn(caller arn.ARN, instanceId string, region string) string {
+	resource := arn.ARN{Partition: caller.Partition, Service: "ec2", Region: region, AccountID: caller.AccountID, Resource: "instance/" + instanceId}
+	if strings.HasPrefix(instanceId, "mi-") {
+		resource.Service = "ssm"
+		resource.Resource = "managed-instance/" + instanceId
+	}
+
+	return resource.String()
+}
+
+func init() {
+	EnumParametersCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumParametersCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	EnumParametersCmd.Flags().BoolVar(&allRegions, "all-regions", false, "Enumerate every region")
+
+	GetParametersCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	GetParametersCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	GetParametersCmd.Flags().BoolVar(&allRegions, "all-regions", false, "Enumerate every region")
+	GetParametersCmd.Flags().BoolVar(&decrypt, "decrypt", false, "Decrypt SecureString values")
+
+	EnumManagedInstancesCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumManagedInstancesCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	EnumManagedInstancesCmd.Flags().BoolVar(&allRegions, "all-regions", false, "Enumerate every region")
+	EnumManagedInstancesCmd.Flags().BoolVar(&withScps, "scps", false, "Also evaluate the organization's SCPs, needs Organizations read access (SCP conditions aren't evaluated)")
+
+	EnumDocumentsCmd.Flags().StringVarP(&region, "region", "r", "", "AWS region")
+	EnumDocumentsCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile")
+	EnumDocumentsCmd.Flags().BoolVar(&allRegions, "all-regions", false, "Enumerate every region")
+	EnumDocumentsCmd.Flags().BoolVar(&sharedOnly, "shared-only", false, "Only show documents shared publicly or with other accounts, or whose sharing couldn't be read")
+}
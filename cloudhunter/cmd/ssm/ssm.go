@@ -0,0 +1,15 @@
+package ssm
+
+import "github.com/spf13/cobra"
+
+var SsmCmd = &cobra.Command{
+	Use:   "ssm",
+	Short: "Interact with AWS Systems Manager service",
+}
+
+func init() {
+	SsmCmd.AddCommand(EnumParametersCmd)
+	SsmCmd.AddCommand(GetParametersCmd)
+	SsmCmd.AddCommand(EnumManagedInstancesCmd)
+	SsmCmd.AddCommand(EnumDocumentsCmd)
+}
@@ -0,0 +1,36 @@
+package aws
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Kimi99/cloudhunter/internal/shared"
+)
+
+var wrapperTypes = []any{
+	AutoScalingWrapper{}, DetectionWrapper{}, Ec2Wrapper{}, IamWrapper{}, KmsWrapper{}, LambdaWrapper{}, OrganizationsWrapper{},
+	RdsWrapper{}, S3Wrapper{}, S3ControlWrapper{}, SecretsManagerWrapper{}, SsmWrapper{}, StsWrapper{},
+}
+
+// TestWrapperActivitiesMatchWrappers keeps the --plan registry in line with the wrappers:
+// every exported method needs an entry, and every entry has to name an existing method.
+func TestWrapperActivitiesMatchWrappers(t *testing.T) {
+	methods := map[string]bool{"InitializeS3ControlWrapper": true}
+	for _, wrapper := range wrapperTypes {
+		wrapperType := reflect.TypeOf(wrapper)
+		for i := 0; i < wrapperType.NumMethod(); i++ {
+			methods[wrapperType.Name()+"."+wrapperType.Method(i).Name] = true
+		}
+	}
+
+	for name := range methods {
+		if _, ok := shared.WrapperActivities[name]; !ok {
+			t.Errorf("%s has no entry in shared.WrapperActivities", name)
+		}
+	}
+	for name := range shared.WrapperActivities {
+		if !methods[name] {
+			t.Errorf("shared.WrapperActivities lists %s, which doesn't exist", name)
+		}
+	}
+}
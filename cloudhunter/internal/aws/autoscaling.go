@@ -0,0 +1,40 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+)
+
+// AutoScalingWrapper encapsulates Amazon EC2 Auto Scaling actions.
+// It contains AutoScalingClient, an Auto Scaling service client bound to a single region.
+type AutoScalingWrapper struct {
+	AutoScalingClient *autoscaling.Client
+}
+
+func InitializeAutoScalingWrapper(ctx context.Context, region string, profile string) AutoScalingWrapper {
+	cfg, err := shared.GetAWSConfig(ctx, region, profile)
+	if err != nil {
+		shared.Fatal(err)
+	}
+
+	client := autoscaling.NewFromConfig(cfg)
+	return AutoScalingWrapper{AutoScalingClient: client}
+}
+
+func (wrapper AutoScalingWrapper) DescribeLaunchConfigurationsWrapper(ctx context.Context) ([]types.LaunchConfiguration, error) {
+	paginator := autoscaling.NewDescribeLaunchConfigurationsPaginator(wrapper.AutoScalingClient, &autoscaling.DescribeLaunchConfigurationsInput{})
+
+	var configurations []types.LaunchConfiguration
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return configurations, err
+		}
+		configurations = append(configurations, page.LaunchConfigurations...)
+	}
+
+	return configurations, nil
+}
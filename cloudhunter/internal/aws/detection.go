@@ -0,0 +1,223 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/accessanalyzer"
+	analyzertypes "github.com/aws/aws-sdk-go-v2/service/accessanalyzer/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	trailtypes "github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/configservice"
+	configtypes "github.com/aws/aws-sdk-go-v2/service/configservice/types"
+	"github.com/aws/aws-sdk-go-v2/service/guardduty"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub"
+	securityhubtypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/aws/smithy-go"
+)
+
+// DetectionWrapper encapsulates the logging and detection services defenders rely on:
+// CloudTrail, GuardDuty, Security Hub, AWS Config, IAM Access Analyzer and CloudWatch.
+// All clients are bound to a single region.
+type DetectionWrapper struct {
+	CloudTrailClient     *cloudtrail.Client
+	GuardDutyClient      *guardduty.Client
+	SecurityHubClient    *securityhub.Client
+	ConfigClient         *configservice.Client
+	AccessAnalyzerClient *accessanalyzer.Client
+	CloudWatchClient     *cloudwatch.Client
+}
+
+const (
+	s3ObjectResourceType       = "AWS::S3::Object"
+	lambdaFunctionResourceType = "AWS::Lambda::Function"
+)
+
+func InitializeDetectionWrapper(ctx context.Context, region string, profile string) DetectionWrapper {
+	cfg, err := shared.GetAWSConfig(ctx, region, profile)
+	if err != nil {
+		shared.Fatal(err)
+	}
+
+	return DetectionWrapper{
+		CloudTrailClient:     cloudtrail.NewFromConfig(cfg),
+		GuardDutyClient:      guardduty.NewFromConfig(cfg),
+		SecurityHubClient:    securityhub.NewFromConfig(cfg),
+		ConfigClient:         configservice.NewFromConfig(cfg),
+		AccessAnalyzerClient: accessanalyzer.NewFromConfig(cfg),
+		CloudWatchClient:     cloudwatch.NewFromConfig(cfg),
+	}
+}
+
+// DescribeTrailsWrapper returns the trails of the region, including multi-region and
+// organization trails that were created elsewhere.
+func (wrapper DetectionWrapper) DescribeTrailsWrapper(ctx context.Context) ([]trailtypes.Trail, error) {
+	output, err := wrapper.CloudTrailClient.DescribeTrails(ctx, &cloudtrail.DescribeTrailsInput{
+		IncludeShadowTrails: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return output.TrailList, nil
+}
+
+func (wrapper DetectionWrapper) GetTrailStatusWrapper(ctx context.Context, trailArn string) (*cloudtrail.GetTrailStatusOutput, error) {
+	return wrapper.CloudTrailClient.GetTrailStatus(ctx, &cloudtrail.GetTrailStatusInput{
+		Name: aws.String(trailArn),
+	})
+}
+
+func (wrapper DetectionWrapper) GetEventSelectorsWrapper(ctx context.Context, trailArn string) (*cloudtrail.GetEventSelectorsOutput, error) {
+	return wrapper.CloudTrailClient.GetEventSelectors(ctx, &cloudtrail.GetEventSelectorsInput{
+		TrailName: aws.String(trailArn),
+	})
+}
+
+// TrailCoverage works out which event categories the trail's basic or advanced event selectors record.
+// Filters other than the event category, resource type and read only flag are ignored, so the
+// coverage errs on the side of reporting events as logged.
+func TrailCoverage(selectors *cloudtrail.GetEventSelectorsOutput) shared.LoggingCoverage {
+	var coverage shared.LoggingCoverage
+
+	for _, selector := range selectors.EventSelectors {
+		reads := selector.ReadWriteType != trailtypes.ReadWriteTypeWriteOnly
+		writes := selector.ReadWriteType != trailtypes.ReadWriteTypeReadOnly
+
+		if aws.ToBool(selector.IncludeManagementEvents) || selector.IncludeManagementEvents == nil {
+			coverage = coverage.Merge(shared.LoggingCoverage{ManagementRead: reads, ManagementWrite: writes})
+		}
+		for _, resource := range selector.DataResources {
+			switch aws.ToString(resource.Type) {
+			case s3ObjectResourceType:
+				coverage = coverage.Merge(shared.LoggingCoverage{S3DataRead: reads, S3DataWrite: writes})
+			case lambdaFunctionResourceType:
+				coverage.LambdaData = true
+			}
+		}
+	}
+
+	for _, selector := range selectors.AdvancedEventSelectors {
+		fields := make(map[string][]string)
+		for _, field := range selector.FieldSelectors {
+			fields[aws.ToString(field.Field)] = field.Equals
+		}
+
+		reads := !slices.Equal(fields["readOnly"], []string{"false"})
+		writes := !slices.Equal(fields["readOnly"], []string{"true"})
+
+		switch {
+		case slices.Contains(fields["eventCategory"], "Management"):
+			coverage = coverage.Merge(shared.LoggingCoverage{ManagementRead: reads, ManagementWrite: writes})
+		case slices.Contains(fields["eventCategory"], "Data") && slices.Contains(fields["resources.type"], s3ObjectResourceType):
+			coverage = coverage.Merge(shared.LoggingCoverage{S3DataRead: reads, S3DataWrite: writes})
+		case slices.Contains(fields["eventCategory"], "Data") && slices.Contains(fields["resources.type"], lambdaFunctionResourceType):
+			coverage.LambdaData = true
+		}
+	}
+
+	return coverage
+}
+
+func (wrapper DetectionWrapper) ListDetectorsWrapper(ctx context.Context) ([]string, error) {
+	paginator := guardduty.NewListDetectorsPaginator(wrapper.GuardDutyClient, &guardduty.ListDetectorsInput{})
+
+	var detectorIds []string
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return detectorIds, err
+		}
+		detectorIds = append(detectorIds, page.DetectorIds...)
+	}
+
+	return detectorIds, nil
+}
+
+func (wrapper DetectionWrapper) GetDetectorWrapper(ctx context.Context, detectorId string) (*guardduty.GetDetectorOutput, error) {
+	return wrapper.GuardDutyClient.GetDetector(ctx, &guardduty.GetDetectorInput{
+		DetectorId: aws.String(detectorId),
+	})
+}
+
+// DescribeHubWrapper returns the Security Hub configuration, or nil if Security Hub is not enabled.
+func (wrapper DetectionWrapper) DescribeHubWrapper(ctx context.Context) (*securityhub.DescribeHubOutput, error) {
+	output, err := wrapper.SecurityHubClient.DescribeHub(ctx, &securityhub.DescribeHubInput{})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidAccessException" {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return output, nil
+}
+
+func (wrapper DetectionWrapper) GetEnabledStandardsWrapper(ctx context.Context) ([]securityhubtypes.StandardsSubscription, error) {
+	paginator := securityhub.NewGetEnabledStandardsPaginator(wrapper.SecurityHubClient, &securityhub.GetEnabledStandardsInput{})
+
+	var standards []securityhubtypes.StandardsSubscription
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return standards, err
+		}
+		standards = append(standards, page.StandardsSubscriptions...)
+	}
+
+	return standards, nil
+}
+
+func (wrapper DetectionWrapper) DescribeConfigurationRecordersWrapper(ctx context.Context) ([]configtypes.ConfigurationRecorder, error) {
+	output, err := wrapper.ConfigClient.DescribeConfigurationRecorders(ctx, &configservice.DescribeConfigurationRecordersInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	return output.ConfigurationRecorders, nil
+}
+
+func (wrapper DetectionWrapper) DescribeConfigurationRecorderStatusWrapper(ctx context.Context) ([]configtypes.ConfigurationRecorderStatus, error) {
+	output, err := wrapper.ConfigClient.DescribeConfigurationRecorderStatus(ctx, &configservice.DescribeConfigurationRecorderStatusInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	return output.ConfigurationRecordersStatus, nil
+}
+
+func (wrapper DetectionWrapper) ListAnalyzersWrapper(ctx context.Context) ([]analyzertypes.AnalyzerSummary, error) {
+	paginator := accessanalyzer.NewListAnalyzersPaginator(wrapper.AccessAnalyzerClient, &accessanalyzer.ListAnalyzersInput{})
+
+	var analyzers []analyzertypes.AnalyzerSummary
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return analyzers, err
+		}
+		analyzers = append(analyzers, page.Analyzers...)
+	}
+
+	return analyzers, nil
+}
+
+func (wrapper DetectionWrapper) DescribeAlarmsWrapper(ctx context.Context) ([]cloudwatchtypes.MetricAlarm, error) {
+	paginator := cloudwatch.NewDescribeAlarmsPaginator(wrapper.CloudWatchClient, &cloudwatch.DescribeAlarmsInput{})
+
+	var alarms []cloudwatchtypes.MetricAlarm
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return alarms, err
+		}
+		alarms = append(alarms, page.MetricAlarms...)
+	}
+
+	return alarms, nil
+}
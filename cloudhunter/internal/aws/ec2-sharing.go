@@ -0,0 +1,106 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// PublicGroup is the permission group, and the restorable-by value, that stands for every AWS account.
+const PublicGroup = "all"
+
+// DescribeSnapshotsWrapper returns the EBS snapshots owned by the given owners ("self" for
+// the caller's account). When restorableBy is set only snapshots restorable by those
+// accounts are returned, so passing PublicGroup lists public snapshots.
+func (wrapper Ec2Wrapper) DescribeSnapshotsWrapper(ctx context.Context, owners []string, restorableBy []string) ([]types.Snapshot, error) {
+	paginator := ec2.NewDescribeSnapshotsPaginator(wrapper.Ec2Client, &ec2.DescribeSnapshotsInput{
+		OwnerIds:            owners,
+		RestorableByUserIds: restorableBy,
+	})
+
+	var snapshots []types.Snapshot
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return snapshots, err
+		}
+		snapshots = append(snapshots, page.Snapshots...)
+	}
+
+	return snapshots, nil
+}
+
+// DescribeImagesWrapper returns the AMIs owned by the given owners, optionally only those
+// executable by the given accounts. Deprecated and disabled images are included.
+func (wrapper Ec2Wrapper) DescribeImagesWrapper(ctx context.Context, owners []string, executableBy []string) ([]types.Image, error) {
+	paginator := ec2.NewDescribeImagesPaginator(wrapper.Ec2Client, &ec2.DescribeImagesInput{
+		Owners:            owners,
+		ExecutableUsers:   executableBy,
+		IncludeDeprecated: aws.Bool(true),
+		IncludeDisabled:   aws.Bool(true),
+	})
+
+	var images []types.Image
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return images, err
+		}
+		images = append(images, page.Images...)
+	}
+
+	return images, nil
+}
+
+// GetSnapshotSharingWrapper reads the createVolumePermission attribute of the snapshot.
+func (wrapper Ec2Wrapper) GetSnapshotSharingWrapper(ctx context.Context, snapshotId string) (shared.ResourceSharing, error) {
+	output, err := wrapper.Ec2Client.DescribeSnapshotAttribute(ctx, &ec2.DescribeSnapshotAttributeInput{
+		SnapshotId: aws.String(snapshotId),
+		Attribute:  types.SnapshotAttributeNameCreateVolumePermission,
+	})
+	if err != nil {
+		return shared.UnknownSharing(err), err
+	}
+
+	var sharing shared.ResourceSharing
+	for _, permission := range output.CreateVolumePermissions {
+		if permission.Group == types.PermissionGroupAll {
+			sharing.Public = true
+		}
+		if permission.UserId != nil {
+			sharing.Accounts = append(sharing.Accounts, *permission.UserId)
+		}
+	}
+
+	return sharing, nil
+}
+
+// GetImageSharingWrapper reads the launchPermission attribute of the AMI.
+func (wrapper Ec2Wrapper) GetImageSharingWrapper(ctx context.Context, imageId string) (shared.ResourceSharing, error) {
+	output, err := wrapper.Ec2Client.DescribeImageAttribute(ctx, &ec2.DescribeImageAttributeInput{
+		ImageId:   aws.String(imageId),
+		Attribute: types.ImageAttributeNameLaunchPermission,
+	})
+	if err != nil {
+		return shared.UnknownSharing(err), err
+	}
+
+	var sharing shared.ResourceSharing
+	for _, permission := range output.LaunchPermissions {
+		switch {
+		case permission.Group == types.PermissionGroupAll:
+			sharing.Public = true
+		case permission.UserId != nil:
+			sharing.Accounts = append(sharing.Accounts, *permission.UserId)
+		case permission.OrganizationArn != nil:
+			sharing.Organizations = append(sharing.Organizations, *permission.OrganizationArn)
+		case permission.OrganizationalUnitArn != nil:
+			sharing.Organizations = append(sharing.Organizations, *permission.OrganizationalUnitArn)
+		}
+	}
+
+	return sharing, nil
+}
@@ -0,0 +1,180 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// Ec2Wrapper encapsulates Amazon Elastic Compute Cloud (Amazon EC2) actions.
+// It contains Ec2Client, an EC2 service client bound to a single region.
+type Ec2Wrapper struct {
+	Ec2Client *ec2.Client
+}
+
+func InitializeEc2Wrapper(ctx context.Context, region string, profile string) Ec2Wrapper {
+	cfg, err := shared.GetAWSConfig(ctx, region, profile)
+	if err != nil {
+		shared.Fatal(err)
+	}
+
+	client := ec2.NewFromConfig(cfg)
+	return Ec2Wrapper{Ec2Client: client}
+}
+
+func (wrapper Ec2Wrapper) DescribeInstancesWrapper(ctx context.Context) ([]types.Instance, error) {
+	paginator := ec2.NewDescribeInstancesPaginator(wrapper.Ec2Client, &ec2.DescribeInstancesInput{})
+
+	var instances []types.Instance
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return instances, err
+		}
+
+		for _, reservation := range page.Reservations {
+			instances = append(instances, reservation.Instances...)
+		}
+	}
+
+	return instances, nil
+}
+
+func (wrapper Ec2Wrapper) DescribeSecurityGroupsWrapper(ctx context.Context) ([]types.SecurityGroup, error) {
+	paginator := ec2.NewDescribeSecurityGroupsPaginator(wrapper.Ec2Client, &ec2.DescribeSecurityGroupsInput{})
+
+	var groups []types.SecurityGroup
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return groups, err
+		}
+		groups = append(groups, page.SecurityGroups...)
+	}
+
+	return groups, nil
+}
+
+func (wrapper Ec2Wrapper) DescribeKeyPairsWrapper(ctx context.Context) ([]types.KeyPairInfo, error) {
+	output, err := wrapper.Ec2Client.DescribeKeyPairs(ctx, &ec2.DescribeKeyPairsInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	return output.KeyPairs, nil
+}
+
+func (wrapper Ec2Wrapper) DescribeVpcsWrapper(ctx context.Context) ([]types.Vpc, error) {
+	paginator := ec2.NewDescribeVpcsPaginator(wrapper.Ec2Client, &ec2.DescribeVpcsInput{})
+
+	var vpcs []types.Vpc
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return vpcs, err
+		}
+		vpcs = append(vpcs, page.Vpcs...)
+	}
+
+	return vpcs, nil
+}
+
+func (wrapper Ec2Wrapper) DescribeSubnetsWrapper(ctx context.Context) ([]types.Subnet, error) {
+	paginator := ec2.NewDescribeSubnetsPaginator(wrapper.Ec2Client, &ec2.DescribeSubnetsInput{})
+
+	var subnets []types.Subnet
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return subnets, err
+		}
+		subnets = append(subnets, page.Subnets...)
+	}
+
+	return subnets, nil
+}
+
+func (wrapper Ec2Wrapper) DescribeNetworkInterfacesWrapper(ctx context.Context) ([]types.NetworkInterface, error) {
+	paginator := ec2.NewDescribeNetworkInterfacesPaginator(wrapper.Ec2Client, &ec2.DescribeNetworkInterfacesInput{})
+
+	var interfaces []types.NetworkInterface
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return interfaces, err
+		}
+		interfaces = append(interfaces, page.NetworkInterfaces...)
+	}
+
+	return interfaces, nil
+}
+
+// GetInstanceUserDataWrapper returns the base64 encoded user data of the instance, or an empty string if it has none.
+func (wrapper Ec2Wrapper) GetInstanceUserDataWrapper(ctx context.Context, instanceId string) (string, error) {
+	output, err := wrapper.Ec2Client.DescribeInstanceAttribute(ctx, &ec2.DescribeInstanceAttributeInput{
+		InstanceId: aws.String(instanceId),
+		Attribute:  types.InstanceAttributeNameUserData,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if output.UserData == nil {
+		return "", nil
+	}
+
+	return aws.ToString(output.UserData.Value), nil
+}
+
+func (wrapper Ec2Wrapper) DescribeLaunchTemplatesWrapper(ctx context.Context) ([]types.LaunchTemplate, error) {
+	paginator := ec2.NewDescribeLaunchTemplatesPaginator(wrapper.Ec2Client, &ec2.DescribeLaunchTemplatesInput{})
+
+	var templates []types.LaunchTemplate
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return templates, err
+		}
+		templates = append(templates, page.LaunchTemplates...)
+	}
+
+	return templates, nil
+}
+
+// DescribeLaunchTemplateVersionsWrapper returns every version of the launch template.
+func (wrapper Ec2Wrapper) DescribeLaunchTemplateVersionsWrapper(ctx context.Context, templateId string) ([]types.LaunchTemplateVersion, error) {
+	paginator := ec2.NewDescribeLaunchTemplateVersionsPaginator(wrapper.Ec2Client, &ec2.DescribeLaunchTemplateVersionsInput{
+		LaunchTemplateId: aws.String(templateId),
+	})
+
+	var versions []types.LaunchTemplateVersion
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return versions, err
+		}
+		versions = append(versions, page.LaunchTemplateVersions...)
+	}
+
+	return versions, nil
+}
+
+// IsOpenToWorld reports whether the rule allows traffic from any IPv4 or IPv6 address.
+func IsOpenToWorld(permission types.IpPermission) bool {
+	for _, ipRange := range permission.IpRanges {
+		if aws.ToString(ipRange.CidrIp) == "0.0.0.0/0" {
+			return true
+		}
+	}
+
+	for _, ipRange := range permission.Ipv6Ranges {
+		if aws.ToString(ipRange.CidrIpv6) == "::/0" {
+			return true
+		}
+	}
+
+	return false
+}
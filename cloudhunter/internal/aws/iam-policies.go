@@ -0,0 +1,218 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+// CollectPrincipalPoliciesWrapper gathers the identity policies that apply to an IAM user or
+// role: its inline and attached managed policies and, for users, those of their groups.
+// Policies that can't be read are logged and skipped, so the result may be incomplete.
+func (wrapper IamWrapper) CollectPrincipalPoliciesWrapper(ctx context.Context, principalArn string) ([]shared.NamedPolicy, error) {
+	parsed, err := arn.Parse(principalArn)
+	if err != nil {
+		return nil, err
+	}
+
+	kind, name, _ := strings.Cut(parsed.Resource, "/")
+	name = name[strings.LastIndex(name, "/")+1:]
+
+	var policies []shared.NamedPolicy
+	switch kind {
+	case "user":
+		policies = wrapper.principalPolicies(ctx, "user", name, wrapper.ListUserPoliciesWrapper, wrapper.GetUserPolicyWrapper, wrapper.ListAttachedUserPoliciesWrapper)
+
+		groups, err := wrapper.ListGroupsForUserWrapper(ctx, name)
+		if err != nil {
+			log.Printf("[!] Couldn't list groups of user %s: %v", name, err)
+		}
+		for _, group := range groups {
+			policies = append(policies, wrapper.principalPolicies(ctx, "group", aws.ToString(group.GroupName),
+				wrapper.ListGroupPoliciesWrapper, wrapper.GetGroupPolicyDocumentWrapper, wrapper.ListAttachedGroupPoliciesWrapper)...)
+		}
+	case "role":
+		policies = wrapper.principalPolicies(ctx, "role", name, wrapper.ListRolePoliciesWrapper, wrapper.GetRolePolicyDocumentWrapper, wrapper.ListAttachedRolePoliciesWrapper)
+	default:
+		return nil, fmt.Errorf("policies can only be collected for IAM users and roles, not %s", principalArn)
+	}
+
+	return policies, nil
+}
+
+// principalPolicies reads the inline and attached managed policies of a single user, group
+// or role through the wrappers for that kind of principal.
+func (wrapper IamWrapper) principalPolicies(ctx context.Context, kind string, name string,
+	listInline func(context.Context, string) ([]string, error),
+	getInline func(context.Context, string, string) (string, error),
+	listAttached func(context.Context, string) ([]types.AttachedPolicy, error)) []shared.NamedPolicy {
+	var policies []shared.NamedPolicy
+
+	policyNames, err := listInline(ctx, name)
+	if err != nil {
+		log.Printf("[!] Couldn't list inline policies of %s %s: %v", kind, name, err)
+	}
+	for _, policyName := range policyNames {
+		document, err := getInline(ctx, name, policyName)
+		source := fmt.Sprintf("inline %s policy %s", kind, policyName)
+		if policy, ok := parseNamedPolicy(source, aws.String(document), err); ok {
+			policies = append(policies, policy)
+		}
+	}
+
+	attached, err := listAttached(ctx, name)
+	if err != nil {
+		log.Printf("[!] Couldn't list attached policies of %s %s: %v", kind, name, err)
+	}
+	for _, policy := range attached {
+		document, err := wrapper.GetManagedPolicyDocumentWrapper(ctx, aws.ToString(policy.PolicyArn))
+		source := fmt.Sprintf("managed policy %s attached to %s %s", aws.ToString(policy.PolicyName), kind, name)
+		if named, ok := parseNamedPolicy(source, aws.String(document), err); ok {
+			policies = append(policies, named)
+		}
+	}
+
+	return policies
+}
+
+// ListAttachedUserPoliciesWrapper returns the managed policies attached to the user, following pagination.
+func (wrapper IamWrapper) ListAttachedUserPoliciesWrapper(ctx context.Context, username string) ([]types.AttachedPolicy, error) {
+	paginator := iam.NewListAttachedUserPoliciesPaginator(wrapper.IamClient, &iam.ListAttachedUserPoliciesInput{
+		UserName: aws.String(username),
+	})
+
+	var attached []types.AttachedPolicy
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return attached, err
+		}
+		attached = append(attached, page.AttachedPolicies...)
+	}
+
+	return attached, nil
+}
+
+// ListAttachedGroupPoliciesWrapper returns the managed policies attached to the group, following pagination.
+func (wrapper IamWrapper) ListAttachedGroupPoliciesWrapper(ctx context.Context, groupName string) ([]types.AttachedPolicy, error) {
+	paginator := iam.NewListAttachedGroupPoliciesPaginator(wrapper.IamClient, &iam.ListAttachedGroupPoliciesInput{
+		GroupName: aws.String(groupName),
+	})
+
+	var attached []types.AttachedPolicy
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return attached, err
+		}
+		attached = append(attached, page.AttachedPolicies...)
+	}
+
+	return attached, nil
+}
+
+// ListAttachedRolePoliciesWrapper returns the managed policies attached to the role, following pagination.
+func (wrapper IamWrapper) ListAttachedRolePoliciesWrapper(ctx context.Context, roleName string) ([]types.AttachedPolicy, error) {
+	paginator := iam.NewListAttachedRolePoliciesPaginator(wrapper.IamClient, &iam.ListAttachedRolePoliciesInput{
+		RoleName: aws.String(roleName),
+	})
+
+	var attached []types.AttachedPolicy
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return attached, err
+		}
+		attached = append(attached, page.AttachedPolicies...)
+	}
+
+	return attached, nil
+}
+
+// GetManagedPolicyDocumentWrapper returns the document of the default version of a managed policy.
+func (wrapper IamWrapper) GetManagedPolicyDocumentWrapper(ctx context.Context, policyArn string) (string, error) {
+	policy, err := wrapper.IamClient.GetPolicy(ctx, &iam.GetPolicyInput{PolicyArn: aws.String(policyArn)})
+	if err != nil {
+		return "", err
+	}
+
+	version, err := wrapper.IamClient.GetPolicyVersion(ctx, &iam.GetPolicyVersionInput{
+		PolicyArn: aws.String(policyArn),
+		VersionId: policy.Policy.DefaultVersionId,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return aws.ToString(version.PolicyVersion.Document), nil
+}
+
+// ListIdentityPoliciesWrapper returns the inline policies of every user, group and role and
+// the default version of every customer managed policy, read with GetAccountAuthorizationDetails.
+// Policies that can't be parsed are logged and skipped.
+func (wrapper IamWrapper) ListIdentityPoliciesWrapper(ctx context.Context) ([]shared.NamedPolicy, error) {
+	paginator := iam.NewGetAccountAuthorizationDetailsPaginator(wrapper.IamClient, &iam.GetAccountAuthorizationDetailsInput{
+		Filter: []types.EntityType{types.EntityTypeUser, types.EntityTypeGroup, types.EntityTypeRole, types.EntityTypeLocalManagedPolicy},
+	})
+
+	var policies []shared.NamedPolicy
+	add := func(source string, document *string) {
+		if policy, ok := parseNamedPolicy(source, document, nil); ok {
+			policies = append(policies, policy)
+		}
+	}
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return policies, err
+		}
+
+		for _, user := range page.UserDetailList {
+			for _, policy := range user.UserPolicyList {
+				add(fmt.Sprintf("inline policy %s of user %s", aws.ToString(policy.PolicyName), aws.ToString(user.UserName)), policy.PolicyDocument)
+			}
+		}
+		for _, group := range page.GroupDetailList {
+			for _, policy := range group.GroupPolicyList {
+				add(fmt.Sprintf("inline policy %s of group %s", aws.ToString(policy.PolicyName), aws.ToString(group.GroupName)), policy.PolicyDocument)
+			}
+		}
+		for _, role := range page.RoleDetailList {
+			for _, policy := range role.RolePolicyList {
+				add(fmt.Sprintf("inline policy %s of role %s", aws.ToString(policy.PolicyName), aws.ToString(role.RoleName)), policy.PolicyDocument)
+			}
+		}
+		for _, policy := range page.Policies {
+			for _, version := range policy.PolicyVersionList {
+				if version.IsDefaultVersion {
+					add("managed policy "+aws.ToString(policy.PolicyName), version.Document)
+				}
+			}
+		}
+	}
+
+	return policies, nil
+}
+
+func parseNamedPolicy(source string, document *string, err error) (shared.NamedPolicy, bool) {
+	if err != nil {
+		log.Printf("[!] Couldn't read %s: %v", source, err)
+		return shared.NamedPolicy{}, false
+	}
+
+	parsed, err := shared.ParsePolicyDocument(aws.ToString(document))
+	if err != nil {
+		log.Printf("[!] Couldn't parse %s: %v", source, err)
+		return shared.NamedPolicy{}, false
+	}
+
+	return shared.NamedPolicy{Name: source, Document: parsed}, true
+}
@@ -0,0 +1,90 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+// GetRoleTrustPolicyWrapper returns the decoded trust policy of the role.
+func (wrapper IamWrapper) GetRoleTrustPolicyWrapper(ctx context.Context, roleName string) (string, error) {
+	output, err := wrapper.IamClient.GetRole(ctx, &iam.GetRoleInput{
+		RoleName: aws.String(roleName),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if output.Role == nil {
+		return "", fmt.Errorf("no role returned for %s", roleName)
+	}
+
+	return url.QueryUnescape(aws.ToString(output.Role.AssumeRolePolicyDocument))
+}
+
+func (wrapper IamWrapper) UpdateAssumeRolePolicyWrapper(ctx context.Context, roleName string, document string) error {
+	_, err := wrapper.IamClient.UpdateAssumeRolePolicy(ctx, &iam.UpdateAssumeRolePolicyInput{
+		RoleName:       aws.String(roleName),
+		PolicyDocument: aws.String(document),
+	})
+
+	return err
+}
+
+// PrincipalExistsWrapper checks whether the principal exists by naming it in the trust policy
+// of one of our own roles: IAM rejects policies with principals that don't exist. The probe
+// policy is the original trust policy with one more statement that denies the principal, so
+// everyone who could assume the role still can while it is in place. The caller has to
+// restore the original trust policy afterwards.
+func (wrapper IamWrapper) PrincipalExistsWrapper(ctx context.Context, roleName string, trustPolicy string, principalArn string) (bool, error) {
+	document, err := withDenyStatement(trustPolicy, principalArn)
+	if err != nil {
+		return false, err
+	}
+
+	err = wrapper.UpdateAssumeRolePolicyWrapper(ctx, roleName, document)
+	if err == nil {
+		return true, nil
+	}
+
+	var malformed *types.MalformedPolicyDocumentException
+	if errors.As(err, &malformed) && strings.Contains(strings.ToLower(malformed.ErrorMessage()), "invalid principal") {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// withDenyStatement appends a statement denying sts:AssumeRole to the principal to the policy.
+// The other elements are kept as they are.
+func withDenyStatement(policy string, principalArn string) (string, error) {
+	var document map[string]any
+	if err := json.Unmarshal([]byte(policy), &document); err != nil {
+		return "", fmt.Errorf("couldn't parse trust policy: %w", err)
+	}
+
+	var statements []any
+	switch existing := document["Statement"].(type) {
+	case []any:
+		statements = existing
+	case nil:
+	default:
+		statements = []any{existing}
+	}
+
+	document["Statement"] = append(statements, map[string]any{
+		"Effect":    "Deny",
+		"Principal": map[string]any{"AWS": principalArn},
+		"Action":    "sts:AssumeRole",
+	})
+
+	probe, err := json.Marshal(document)
+	return string(probe), err
+}
@@ -0,0 +1,61 @@
+package aws
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestWithDenyStatement(t *testing.T) {
+	const principal = "arn:aws:iam::222222222222:root"
+	deny := map[string]any{"Effect": "Deny", "Principal": map[string]any{"AWS": principal}, "Action": "sts:AssumeRole"}
+	allow := map[string]any{"Effect": "Allow", "Principal": map[string]any{"Service": "lambda.amazonaws.com"}, "Action": "sts:AssumeRole"}
+	conditional := map[string]any{"Effect": "Allow", "Principal": map[string]any{"AWS": "arn:aws:iam::111111111111:root"}, "Action": "sts:AssumeRole",
+		"Condition": map[string]any{"StringEquals": map[string]any{"sts:ExternalId": "secret"}}}
+
+	tests := []struct {
+		name   string
+		policy string
+		want   []any
+	}{
+		{
+			name:   "statement list",
+			policy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"lambda.amazonaws.com"},"Action":"sts:AssumeRole"},{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111111111111:root"},"Action":"sts:AssumeRole","Condition":{"StringEquals":{"sts:ExternalId":"secret"}}}]}`,
+			want:   []any{allow, conditional, deny},
+		},
+		{
+			name:   "single statement",
+			policy: `{"Version":"2012-10-17","Statement":{"Effect":"Allow","Principal":{"Service":"lambda.amazonaws.com"},"Action":"sts:AssumeRole"}}`,
+			want:   []any{allow, deny},
+		},
+		{
+			name:   "no statements",
+			policy: `{"Version":"2012-10-17"}`,
+			want:   []any{deny},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			probe, err := withDenyStatement(test.policy, principal)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var document map[string]any
+			if err := json.Unmarshal([]byte(probe), &document); err != nil {
+				t.Fatalf("probe policy isn't valid JSON: %v", err)
+			}
+			if document["Version"] != "2012-10-17" {
+				t.Errorf("version wasn't kept: %v", document["Version"])
+			}
+			if !reflect.DeepEqual(document["Statement"], test.want) {
+				t.Errorf("got statements %v, want %v", document["Statement"], test.want)
+			}
+		})
+	}
+
+	if _, err := withDenyStatement("not json", principal); err == nil {
+		t.Error("expected an error for a policy that isn't JSON")
+	}
+}
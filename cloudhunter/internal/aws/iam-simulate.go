@@ -0,0 +1,60 @@
+package aws
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+// CallerPrincipalArnWrapper turns the ARN returned by GetCallerIdentity into the IAM ARN of
+// the principal. Assumed role sessions are mapped back to their role, whose ARN is read
+// from IAM because the session ARN doesn't carry the role's path.
+func (wrapper IamWrapper) CallerPrincipalArnWrapper(ctx context.Context, callerArn string) (string, error) {
+	parsed, err := arn.Parse(callerArn)
+	if err != nil {
+		return "", err
+	}
+
+	if parsed.Service != "sts" || !strings.HasPrefix(parsed.Resource, "assumed-role/") {
+		return callerArn, nil
+	}
+
+	roleName := strings.Split(parsed.Resource, "/")[1]
+	output, err := wrapper.GetRoleWrapper(ctx, roleName)
+	if err != nil {
+		// Fall back to the path-less role ARN, which is right for most roles.
+		return arn.ARN{Partition: parsed.Partition, Service: "iam", AccountID: parsed.AccountID, Resource: "role/" + roleName}.String(), nil
+	}
+
+	return aws.ToString(output.Role.Arn), nil
+}
+
+// SimulatePrincipalPolicyWrapper evaluates the principal's identity policies for the actions
+// against the resources and returns the actions that would be allowed.
+func (wrapper IamWrapper) SimulatePrincipalPolicyWrapper(ctx context.Context, principalArn string, actions []string, resourceArns []string) ([]string, error) {
+	paginator := iam.NewSimulatePrincipalPolicyPaginator(wrapper.IamClient, &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(principalArn),
+		ActionNames:     actions,
+		ResourceArns:    resourceArns,
+	})
+
+	var allowed []string
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return allowed, err
+		}
+
+		for _, result := range page.EvaluationResults {
+			if result.EvalDecision == types.PolicyEvaluationDecisionTypeAllowed {
+				allowed = append(allowed, aws.ToString(result.EvalActionName))
+			}
+		}
+	}
+
+	return allowed, nil
+}
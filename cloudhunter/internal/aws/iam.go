@@ -2,7 +2,6 @@ package aws
 
 import (
 	"context"
-	"log"
 
 	"github.com/Kimi99/cloudhunter/internal/shared"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -20,7 +19,7 @@ type IamWrapper struct {
 func InitializeIamWrapper(ctx context.Context, region string, profile string) IamWrapper {
 	cfg, err := shared.GetAWSConfig(ctx, region, profile)
 	if err != nil {
-		log.Fatal(err)
+		shared.Fatal(err)
 	}
 
 	client := iam.NewFromConfig(cfg)
@@ -33,7 +32,7 @@ func (wrapper IamWrapper) ListAccessKeysWrapper(ctx context.Context) ([]types.Ac
 	accessKeys, err := wrapper.IamClient.ListAccessKeys(ctx, &iam.ListAccessKeysInput{})
 
 	if err != nil {
-		log.Fatal(err)
+		shared.Fatal(err)
 	}
 
 	return accessKeys.AccessKeyMetadata, err
@@ -43,7 +42,7 @@ func (wrapper IamWrapper) ListUsersWrapper(ctx context.Context) ([]types.User, e
 	users, err := wrapper.IamClient.ListUsers(ctx, &iam.ListUsersInput{})
 
 	if err != nil {
-		log.Fatal(err)
+		shared.Fatal(err)
 	}
 
 	return users.Users, err
@@ -55,22 +54,28 @@ func (wrapper IamWrapper) GetUserWrapper(ctx context.Context, userName string) (
 	})
 
 	if err != nil {
-		log.Fatal(err)
+		shared.Fatal(err)
 	}
 
 	return *user.User, err
 }
 
+// ListUserPoliciesWrapper returns the names of the user's inline policies, following pagination.
 func (wrapper IamWrapper) ListUserPoliciesWrapper(ctx context.Context, username string) ([]string, error) {
-	policies, err := wrapper.IamClient.ListUserPolicies(ctx, &iam.ListUserPoliciesInput{
+	paginator := iam.NewListUserPoliciesPaginator(wrapper.IamClient, &iam.ListUserPoliciesInput{
 		UserName: aws.String(username),
 	})
 
-	if err != nil {
-		log.Fatal(err)
+	var policyNames []string
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return policyNames, err
+		}
+		policyNames = append(policyNames, page.PolicyNames...)
 	}
 
-	return policies.PolicyNames, err
+	return policyNames, nil
 }
 
 func (wrapper IamWrapper) GetUserPolicyWrapper(ctx context.Context, username string, policyName string) (string, error) {
@@ -80,7 +85,7 @@ func (wrapper IamWrapper) GetUserPolicyWrapper(ctx context.Context, username str
 	})
 
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 
 	policy := shared.ParseJsonPolicyDocument(*policyDocument.PolicyDocument)
@@ -92,22 +97,28 @@ func (wrapper IamWrapper) ListGroupsWrapper(ctx context.Context) ([]types.Group,
 	groups, err := wrapper.IamClient.ListGroups(ctx, &iam.ListGroupsInput{})
 
 	if err != nil {
-		log.Fatal(err)
+		shared.Fatal(err)
 	}
 
 	return groups.Groups, err
 }
 
+// ListGroupsForUserWrapper returns the groups the user belongs to, following pagination.
 func (wrapper IamWrapper) ListGroupsForUserWrapper(ctx context.Context, username string) ([]types.Group, error) {
-	group, err := wrapper.IamClient.ListGroupsForUser(ctx, &iam.ListGroupsForUserInput{
+	paginator := iam.NewListGroupsForUserPaginator(wrapper.IamClient, &iam.ListGroupsForUserInput{
 		UserName: &username,
 	})
 
-	if err != nil {
-		log.Fatal(err)
+	var groups []types.Group
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return groups, err
+		}
+		groups = append(groups, page.Groups...)
 	}
 
-	return group.Groups, err
+	return groups, nil
 }
 
 func (wrapper IamWrapper) GetGroupWrapper(ctx context.Context, groupName string) (*iam.GetGroupOutput, error) {
@@ -116,22 +127,28 @@ func (wrapper IamWrapper) GetGroupWrapper(ctx context.Context, groupName string)
 	})
 
 	if err != nil {
-		log.Fatal(err)
+		shared.Fatal(err)
 	}
 
 	return group, err
 }
 
+// ListGroupPoliciesWrapper returns the names of the group's inline policies, following pagination.
 func (wrapper IamWrapper) ListGroupPoliciesWrapper(ctx context.Context, groupName string) ([]string, error) {
-	policies, err := wrapper.IamClient.ListGroupPolicies(ctx, &iam.ListGroupPoliciesInput{
+	paginator := iam.NewListGroupPoliciesPaginator(wrapper.IamClient, &iam.ListGroupPoliciesInput{
 		GroupName: &groupName,
 	})
 
-	if err != nil {
-		log.Fatal(err)
+	var policyNames []string
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return policyNames, err
+		}
+		policyNames = append(policyNames, page.PolicyNames...)
 	}
 
-	return policies.PolicyNames, err
+	return policyNames, nil
 }
 
 func (wrapper IamWrapper) GetGroupPolicyDocumentWrapper(ctx context.Context, groupName string, policyName string) (string, error) {
@@ -141,7 +158,7 @@ func (wrapper IamWrapper) GetGroupPolicyDocumentWrapper(ctx context.Context, gro
 	})
 
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 
 	policy := shared.ParseJsonPolicyDocument(*policyDocument.PolicyDocument)
@@ -153,34 +170,51 @@ func (wrapper IamWrapper) ListRolesWrapper(ctx context.Context) ([]types.Role, e
 	result, err := wrapper.IamClient.ListRoles(ctx, &iam.ListRolesInput{})
 
 	if err != nil {
-		log.Fatal(err)
+		shared.Fatal(err)
 	}
 
 	return result.Roles, err
 }
 
-func (wrapper IamWrapper) GetRoleWrapper(ctx context.Context, roleName string) (*iam.GetRoleOutput, error) {
-	role, err := wrapper.IamClient.GetRole(ctx, &iam.GetRoleInput{
-		RoleName: &roleName,
-	})
+// ListAllRolesWrapper returns every role of the account, following pagination. Unlike
+// ListRolesWrapper it leaves handling errors to the caller.
+func (wrapper IamWrapper) ListAllRolesWrapper(ctx context.Context) ([]types.Role, error) {
+	paginator := iam.NewListRolesPaginator(wrapper.IamClient, &iam.ListRolesInput{})
 
-	if err != nil {
-		log.Fatal(err)
+	var roles []types.Role
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return roles, err
+		}
+		roles = append(roles, page.Roles...)
 	}
 
-	return role, err
+	return roles, nil
 }
 
+func (wrapper IamWrapper) GetRoleWrapper(ctx context.Context, roleName string) (*iam.GetRoleOutput, error) {
+	return wrapper.IamClient.GetRole(ctx, &iam.GetRoleInput{
+		RoleName: &roleName,
+	})
+}
+
+// ListRolePoliciesWrapper returns the names of the role's inline policies, following pagination.
 func (wrapper IamWrapper) ListRolePoliciesWrapper(ctx context.Context, roleName string) ([]string, error) {
-	result, err := wrapper.IamClient.ListRolePolicies(ctx, &iam.ListRolePoliciesInput{
+	paginator := iam.NewListRolePoliciesPaginator(wrapper.IamClient, &iam.ListRolePoliciesInput{
 		RoleName: &roleName,
 	})
 
-	if err != nil {
-		log.Fatal(err)
+	var policyNames []string
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return policyNames, err
+		}
+		policyNames = append(policyNames, page.PolicyNames...)
 	}
 
-	return result.PolicyNames, err
+	return policyNames, nil
 }
 
 func (wrapper IamWrapper) GetRolePolicyDocumentWrapper(ctx context.Context, roleName string, policyName string) (string, error) {
@@ -190,7 +224,7 @@ func (wrapper IamWrapper) GetRolePolicyDocumentWrapper(ctx context.Context, role
 	})
 
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 
 	policy := shared.ParseJsonPolicyDocument(*policyDocument.PolicyDocument)
@@ -0,0 +1,103 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// KmsWrapper encapsulates AWS Key Management Service actions.
+// It contains KmsClient, a KMS service client bound to a single region.
+type KmsWrapper struct {
+	KmsClient *kms.Client
+}
+
+func InitializeKmsWrapper(ctx context.Context, region string, profile string) KmsWrapper {
+	cfg, err := shared.GetAWSConfig(ctx, region, profile)
+	if err != nil {
+		shared.Fatal(err)
+	}
+
+	client := kms.NewFromConfig(cfg)
+	return KmsWrapper{KmsClient: client}
+}
+
+func (wrapper KmsWrapper) ListKeysWrapper(ctx context.Context) ([]types.KeyListEntry, error) {
+	paginator := kms.NewListKeysPaginator(wrapper.KmsClient, &kms.ListKeysInput{})
+
+	var keys []types.KeyListEntry
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return keys, err
+		}
+		keys = append(keys, page.Keys...)
+	}
+
+	return keys, nil
+}
+
+// ListAliasesWrapper returns the aliases of the region grouped by the ID of the key they point to.
+func (wrapper KmsWrapper) ListAliasesWrapper(ctx context.Context) (map[string][]string, error) {
+	paginator := kms.NewListAliasesPaginator(wrapper.KmsClient, &kms.ListAliasesInput{})
+
+	aliases := make(map[string][]string)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return aliases, err
+		}
+
+		for _, alias := range page.Aliases {
+			if alias.TargetKeyId != nil {
+				aliases[*alias.TargetKeyId] = append(aliases[*alias.TargetKeyId], aws.ToString(alias.AliasName))
+			}
+		}
+	}
+
+	return aliases, nil
+}
+
+func (wrapper KmsWrapper) DescribeKeyWrapper(ctx context.Context, keyId string) (*types.KeyMetadata, error) {
+	output, err := wrapper.KmsClient.DescribeKey(ctx, &kms.DescribeKeyInput{
+		KeyId: aws.String(keyId),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return output.KeyMetadata, nil
+}
+
+// GetKeyPolicyWrapper returns the key policy. Keys only ever have the "default" policy.
+func (wrapper KmsWrapper) GetKeyPolicyWrapper(ctx context.Context, keyId string) (string, error) {
+	output, err := wrapper.KmsClient.GetKeyPolicy(ctx, &kms.GetKeyPolicyInput{
+		KeyId:      aws.String(keyId),
+		PolicyName: aws.String("default"),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return aws.ToString(output.Policy), nil
+}
+
+func (wrapper KmsWrapper) ListGrantsWrapper(ctx context.Context, keyId string) ([]types.GrantListEntry, error) {
+	paginator := kms.NewListGrantsPaginator(wrapper.KmsClient, &kms.ListGrantsInput{
+		KeyId: aws.String(keyId),
+	})
+
+	var grants []types.GrantListEntry
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return grants, err
+		}
+		grants = append(grants, page.Grants...)
+	}
+
+	return grants, nil
+}
@@ -0,0 +1,159 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// LambdaWrapper encapsulates AWS Lambda actions.
+// It contains LambdaClient, a Lambda service client bound to a single region.
+type LambdaWrapper struct {
+	LambdaClient *lambda.Client
+}
+
+func InitializeLambdaWrapper(ctx context.Context, region string, profile string) LambdaWrapper {
+	cfg, err := shared.GetAWSConfig(ctx, region, profile)
+	if err != nil {
+		shared.Fatal(err)
+	}
+
+	client := lambda.NewFromConfig(cfg)
+	return LambdaWrapper{LambdaClient: client}
+}
+
+func (wrapper LambdaWrapper) ListFunctionsWrapper(ctx context.Context) ([]types.FunctionConfiguration, error) {
+	paginator := lambda.NewListFunctionsPaginator(wrapper.LambdaClient, &lambda.ListFunctionsInput{})
+
+	var functions []types.FunctionConfiguration
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return functions, err
+		}
+		functions = append(functions, page.Functions...)
+	}
+
+	return functions, nil
+}
+
+func (wrapper LambdaWrapper) ListFunctionUrlConfigsWrapper(ctx context.Context, functionName string) ([]types.FunctionUrlConfig, error) {
+	paginator := lambda.NewListFunctionUrlConfigsPaginator(wrapper.LambdaClient, &lambda.ListFunctionUrlConfigsInput{
+		FunctionName: aws.String(functionName),
+	})
+
+	var configs []types.FunctionUrlConfig
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return configs, err
+		}
+		configs = append(configs, page.FunctionUrlConfigs...)
+	}
+
+	return configs, nil
+}
+
+// GetPolicyWrapper returns the resource based policy of the function, or an empty string if it has none.
+func (wrapper LambdaWrapper) GetPolicyWrapper(ctx context.Context, functionName string) (string, error) {
+	output, err := wrapper.LambdaClient.GetPolicy(ctx, &lambda.GetPolicyInput{
+		FunctionName: aws.String(functionName),
+	})
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return aws.ToString(output.Policy), nil
+}
+
+func (wrapper LambdaWrapper) ListEventSourceMappingsWrapper(ctx context.Context, functionName string) ([]types.EventSourceMappingConfiguration, error) {
+	paginator := lambda.NewListEventSourceMappingsPaginator(wrapper.LambdaClient, &lambda.ListEventSourceMappingsInput{
+		FunctionName: aws.String(functionName),
+	})
+
+	var mappings []types.EventSourceMappingConfiguration
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return mappings, err
+		}
+		mappings = append(mappings, page.EventSourceMappings...)
+	}
+
+	return mappings, nil
+}
+
+// DownloadFunctionCodeWrapper fetches the deployment package of the function through the
+// presigned Code.Location URL, saves it as <function>.zip in localFolder and extracts it
+// next to it. It returns the directory the package was extracted to.
+func (wrapper LambdaWrapper) DownloadFunctionCodeWrapper(ctx context.Context, functionName string, localFolder string) (string, error) {
+	output, err := wrapper.LambdaClient.GetFunction(ctx, &lambda.GetFunctionInput{
+		FunctionName: aws.String(functionName),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if output.Code == nil || output.Configuration == nil {
+		return "", fmt.Errorf("no code location returned for function %s", functionName)
+	}
+	if output.Configuration.PackageType == types.PackageTypeImage {
+		return "", fmt.Errorf("function is deployed as a container image: %s", aws.ToString(output.Code.ImageUri))
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, aws.ToString(output.Code.Location), nil)
+	if err != nil {
+		return "", err
+	}
+
+	if userAgent := shared.UserAgentHeader(); userAgent != "" {
+		request.Header.Set("User-Agent", userAgent)
+	}
+
+	// Reuse the SDK's HTTP client so the download goes through the same transport settings.
+	response, err := wrapper.LambdaClient.Options().HTTPClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("code download failed with status %s", response.Status)
+	}
+
+	name := filepath.Base(aws.ToString(output.Configuration.FunctionName))
+	if err := os.MkdirAll(localFolder, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	archivePath := filepath.Join(localFolder, name+".zip")
+	archive, err := os.Create(archivePath)
+	if err != nil {
+		return "", err
+	}
+	defer archive.Close()
+
+	if _, err := io.Copy(archive, response.Body); err != nil {
+		return "", err
+	}
+
+	extractedPath := filepath.Join(localFolder, name)
+	if err := shared.ExtractZip(archivePath, extractedPath); err != nil {
+		return "", err
+	}
+
+	return extractedPath, nil
+}
@@ -0,0 +1,288 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/aws/aws-sdk-go-v2/service/organizations/types"
+)
+
+// OrganizationsWrapper encapsulates AWS Organizations actions, which only the management
+// account and delegated administrators are allowed to call.
+type OrganizationsWrapper struct {
+	OrganizationsClient *organizations.Client
+}
+
+func InitializeOrganizationsWrapper(ctx context.Context, region string, profile string) OrganizationsWrapper {
+	cfg, err := shared.GetAWSConfig(ctx, region, profile)
+	if err != nil {
+		shared.Fatal(err)
+	}
+
+	// Organizations is a global service, any region reaches it.
+	if cfg.Region == "" {
+		cfg.Region = shared.DefaultRegion
+	}
+
+	client := organizations.NewFromConfig(cfg)
+	return OrganizationsWrapper{OrganizationsClient: client}
+}
+
+func (wrapper OrganizationsWrapper) DescribeOrganizationWrapper(ctx context.Context) (*types.Organization, error) {
+	output, err := wrapper.OrganizationsClient.DescribeOrganization(ctx, &organizations.DescribeOrganizationInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	return output.Organization, nil
+}
+
+func (wrapper OrganizationsWrapper) ListAccountsWrapper(ctx context.Context) ([]types.Account, error) {
+	paginator := organizations.NewListAccountsPaginator(wrapper.OrganizationsClient, &organizations.ListAccountsInput{})
+
+	var accounts []types.Account
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return accounts, err
+		}
+		accounts = append(accounts, page.Accounts...)
+	}
+
+	return accounts, nil
+}
+
+func (wrapper OrganizationsWrapper) ListRootsWrapper(ctx context.Context) ([]types.Root, error) {
+	paginator := organizations.NewListRootsPaginator(wrapper.OrganizationsClient, &organizations.ListRootsInput{})
+
+	var roots []types.Root
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return roots, err
+		}
+		roots = append(roots, page.Roots...)
+	}
+
+	return roots, nil
+}
+
+// ListOrganizationalUnitsWrapper returns the OUs directly below the root or OU.
+func (wrapper OrganizationsWrapper) ListOrganizationalUnitsWrapper(ctx context.Context, parentId string) ([]types.OrganizationalUnit, error) {
+	paginator := organizations.NewListOrganizationalUnitsForParentPaginator(wrapper.OrganizationsClient, &organizations.ListOrganizationalUnitsForParentInput{
+		ParentId: aws.String(parentId),
+	})
+
+	var units []types.OrganizationalUnit
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return units, err
+		}
+		units = append(units, page.OrganizationalUnits...)
+	}
+
+	return units, nil
+}
+
+// ListAccountsForParentWrapper returns the accounts directly below the root or OU.
+func (wrapper OrganizationsWrapper) ListAccountsForParentWrapper(ctx context.Context, parentId string) ([]types.Account, error) {
+	paginator := organizations.NewListAccountsForParentPaginator(wrapper.OrganizationsClient, &organizations.ListAccountsForParentInput{
+		ParentId: aws.String(parentId),
+	})
+
+	var accounts []types.Account
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return accounts, err
+		}
+		accounts = append(accounts, page.Accounts...)
+	}
+
+	return accounts, nil
+}
+
+// ListParentsWrapper returns the parent of the account or OU. Every child has exactly one.
+func (wrapper OrganizationsWrapper) ListParentsWrapper(ctx context.Context, childId string) ([]types.Parent, error) {
+	paginator := organizations.NewListParentsPaginator(wrapper.OrganizationsClient, &organizations.ListParentsInput{
+		ChildId: aws.String(childId),
+	})
+
+	var parents []types.Parent
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return parents, err
+		}
+		parents = append(parents, page.Parents...)
+	}
+
+	return parents, nil
+}
+
+func (wrapper OrganizationsWrapper) ListServiceControlPoliciesWrapper(ctx context.Context) ([]types.PolicySummary, error) {
+	paginator := organizations.NewListPoliciesPaginator(wrapper.OrganizationsClient, &organizations.ListPoliciesInput{
+		Filter: types.PolicyTypeServiceControlPolicy,
+	})
+
+	var policies []types.PolicySummary
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return policies, err
+		}
+		policies = append(policies, page.Policies...)
+	}
+
+	return policies, nil
+}
+
+// ListServiceControlPoliciesForTargetWrapper returns the SCPs attached directly to the root, OU or account.
+func (wrapper OrganizationsWrapper) ListServiceControlPoliciesForTargetWrapper(ctx context.Context, targetId string) ([]types.PolicySummary, error) {
+	paginator := organizations.NewListPoliciesForTargetPaginator(wrapper.OrganizationsClient, &organizations.ListPoliciesForTargetInput{
+		TargetId: aws.String(targetId),
+		Filter:   types.PolicyTypeServiceControlPolicy,
+	})
+
+	var policies []types.PolicySummary
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return policies, err
+		}
+		policies = append(policies, page.Policies...)
+	}
+
+	return policies, nil
+}
+
+// GetPolicyContentWrapper returns the JSON document of the policy.
+func (wrapper OrganizationsWrapper) GetPolicyContentWrapper(ctx context.Context, policyId string) (string, error) {
+	output, err := wrapper.OrganizationsClient.DescribePolicy(ctx, &organizations.DescribePolicyInput{
+		PolicyId: aws.String(policyId),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if output.Policy == nil {
+		return "", fmt.Errorf("no content returned for policy %s", policyId)
+	}
+
+	return aws.ToString(output.Policy.Content), nil
+}
+
+func (wrapper OrganizationsWrapper) ListTargetsForPolicyWrapper(ctx context.Context, policyId string) ([]types.PolicyTargetSummary, error) {
+	paginator := organizations.NewListTargetsForPolicyPaginator(wrapper.OrganizationsClient, &organizations.ListTargetsForPolicyInput{
+		PolicyId: aws.String(policyId),
+	})
+
+	var targets []types.PolicyTargetSummary
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return targets, err
+		}
+		targets = append(targets, page.Targets...)
+	}
+
+	return targets, nil
+}
+
+func (wrapper OrganizationsWrapper) ListDelegatedAdministratorsWrapper(ctx context.Context) ([]types.DelegatedAdministrator, error) {
+	paginator := organizations.NewListDelegatedAdministratorsPaginator(wrapper.OrganizationsClient, &organizations.ListDelegatedAdministratorsInput{})
+
+	var administrators []types.DelegatedAdministrator
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return administrators, err
+		}
+		administrators = append(administrators, page.DelegatedAdministrators...)
+	}
+
+	return administrators, nil
+}
+
+// ListDelegatedServicesWrapper returns the service principals the account is a delegated administrator for.
+func (wrapper OrganizationsWrapper) ListDelegatedServicesWrapper(ctx context.Context, accountId string) ([]string, error) {
+	paginator := organizations.NewListDelegatedServicesForAccountPaginator(wrapper.OrganizationsClient, &organizations.ListDelegatedServicesForAccountInput{
+		AccountId: aws.String(accountId),
+	})
+
+	var services []string
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return services, err
+		}
+
+		for _, service := range page.DelegatedServices {
+			services = append(services, aws.ToString(service.ServicePrincipal))
+		}
+	}
+
+	return services, nil
+}
+
+// AccountServiceControlPoliciesWrapper returns the SCPs that apply to the account, or none for
+// the organization's management account, which SCPs never restrict.
+func (wrapper OrganizationsWrapper) AccountServiceControlPoliciesWrapper(ctx context.Context, accountId string) ([]shared.ScpLevel, error) {
+	organization, err := wrapper.DescribeOrganizationWrapper(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if accountId == aws.ToString(organization.MasterAccountId) {
+		return nil, nil
+	}
+
+	return wrapper.CollectServiceControlPoliciesWrapper(ctx, accountId)
+}
+
+// CollectServiceControlPoliciesWrapper returns the SCPs that apply to the account, one level
+// per node on the path from the root down to the account itself.
+func (wrapper OrganizationsWrapper) CollectServiceControlPoliciesWrapper(ctx context.Context, accountId string) ([]shared.ScpLevel, error) {
+	var levels []shared.ScpLevel
+
+	targetId := accountId
+	reachedRoot := false
+	for {
+		attached, err := wrapper.ListServiceControlPoliciesForTargetWrapper(ctx, targetId)
+		if err != nil {
+			return nil, err
+		}
+
+		level := shared.ScpLevel{Target: targetId}
+		for _, summary := range attached {
+			content, err := wrapper.GetPolicyContentWrapper(ctx, aws.ToString(summary.Id))
+			if err != nil {
+				return nil, err
+			}
+
+			document, err := shared.ParsePolicyDocument(content)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't parse SCP %s: %w", aws.ToString(summary.Name), err)
+			}
+			level.Policies = append(level.Policies, shared.NamedPolicy{Name: "SCP " + aws.ToString(summary.Name), Document: document})
+		}
+		levels = append([]shared.ScpLevel{level}, levels...)
+
+		if reachedRoot {
+			return levels, nil
+		}
+
+		parents, err := wrapper.ListParentsWrapper(ctx, targetId)
+		if err != nil {
+			return nil, err
+		}
+		if len(parents) == 0 {
+			return levels, nil
+		}
+		targetId = aws.ToString(parents[0].Id)
+		reachedRoot = parents[0].Type == types.ParentTypeRoot
+	}
+}
@@ -0,0 +1,126 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+// RdsWrapper encapsulates Amazon Relational Database Service (Amazon RDS) actions.
+// It contains RdsClient, an RDS service client bound to a single region.
+type RdsWrapper struct {
+	RdsClient *rds.Client
+}
+
+// restoreAttribute is the snapshot attribute listing the accounts allowed to copy or restore it.
+const restoreAttribute = "restore"
+
+func InitializeRdsWrapper(ctx context.Context, region string, profile string) RdsWrapper {
+	cfg, err := shared.GetAWSConfig(ctx, region, profile)
+	if err != nil {
+		shared.Fatal(err)
+	}
+
+	client := rds.NewFromConfig(cfg)
+	return RdsWrapper{RdsClient: client}
+}
+
+// DescribeDBSnapshotsWrapper returns the DB instance snapshots of the given type. An empty
+// type returns the account's own manual and automated snapshots, "public" returns the
+// public snapshots of every account in the region.
+func (wrapper RdsWrapper) DescribeDBSnapshotsWrapper(ctx context.Context, snapshotType string) ([]types.DBSnapshot, error) {
+	input := &rds.DescribeDBSnapshotsInput{}
+	if snapshotType != "" {
+		input.SnapshotType = aws.String(snapshotType)
+		input.IncludePublic = aws.Bool(snapshotType == "public")
+	}
+
+	paginator := rds.NewDescribeDBSnapshotsPaginator(wrapper.RdsClient, input)
+
+	var snapshots []types.DBSnapshot
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return snapshots, err
+		}
+		snapshots = append(snapshots, page.DBSnapshots...)
+	}
+
+	return snapshots, nil
+}
+
+// DescribeDBClusterSnapshotsWrapper is the Aurora counterpart of DescribeDBSnapshotsWrapper.
+func (wrapper RdsWrapper) DescribeDBClusterSnapshotsWrapper(ctx context.Context, snapshotType string) ([]types.DBClusterSnapshot, error) {
+	input := &rds.DescribeDBClusterSnapshotsInput{}
+	if snapshotType != "" {
+		input.SnapshotType = aws.String(snapshotType)
+		input.IncludePublic = aws.Bool(snapshotType == "public")
+	}
+
+	paginator := rds.NewDescribeDBClusterSnapshotsPaginator(wrapper.RdsClient, input)
+
+	var snapshots []types.DBClusterSnapshot
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return snapshots, err
+		}
+		snapshots = append(snapshots, page.DBClusterSnapshots...)
+	}
+
+	return snapshots, nil
+}
+
+// GetDBSnapshotSharingWrapper reads the restore attribute of the DB instance snapshot.
+func (wrapper RdsWrapper) GetDBSnapshotSharingWrapper(ctx context.Context, snapshotId string) (shared.ResourceSharing, error) {
+	output, err := wrapper.RdsClient.DescribeDBSnapshotAttributes(ctx, &rds.DescribeDBSnapshotAttributesInput{
+		DBSnapshotIdentifier: aws.String(snapshotId),
+	})
+	if err != nil || output.DBSnapshotAttributesResult == nil {
+		return shared.UnknownSharing(err), err
+	}
+
+	var sharing shared.ResourceSharing
+	for _, attribute := range output.DBSnapshotAttributesResult.DBSnapshotAttributes {
+		if aws.ToString(attribute.AttributeName) == restoreAttribute {
+			sharing = restoreSharing(attribute.AttributeValues)
+		}
+	}
+
+	return sharing, nil
+}
+
+// GetDBClusterSnapshotSharingWrapper reads the restore attribute of the DB cluster snapshot.
+func (wrapper RdsWrapper) GetDBClusterSnapshotSharingWrapper(ctx context.Context, snapshotId string) (shared.ResourceSharing, error) {
+	output, err := wrapper.RdsClient.DescribeDBClusterSnapshotAttributes(ctx, &rds.DescribeDBClusterSnapshotAttributesInput{
+		DBClusterSnapshotIdentifier: aws.String(snapshotId),
+	})
+	if err != nil || output.DBClusterSnapshotAttributesResult == nil {
+		return shared.UnknownSharing(err), err
+	}
+
+	var sharing shared.ResourceSharing
+	for _, attribute := range output.DBClusterSnapshotAttributesResult.DBClusterSnapshotAttributes {
+		if aws.ToString(attribute.AttributeName) == restoreAttribute {
+			sharing = restoreSharing(attribute.AttributeValues)
+		}
+	}
+
+	return sharing, nil
+}
+
+func restoreSharing(values []string) shared.ResourceSharing {
+	var sharing shared.ResourceSharing
+	for _, value := range values {
+		if value == PublicGroup {
+			sharing.Public = true
+		} else {
+			sharing.Accounts = append(sharing.Accounts, value)
+		}
+	}
+
+	return sharing
+}
@@ -0,0 +1,213 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// ErrObjectBudgetReached is returned together with the partial tree when listing
+// stopped because ListContentOptions.MaxObjects was reached.
+var ErrObjectBudgetReached = errors.New("object budget reached")
+
+// ListContentOptions limits how much of a bucket ListS3BucketContent walks.
+// Zero values mean no limit. OnNode, if set, is called for every node as soon
+// as it is discovered; calls are serialized.
+type ListContentOptions struct {
+	MaxDepth   int
+	MaxObjects int
+	Workers    int
+	OnNode     func(node *shared.S3Node, depth int)
+}
+
+type bucketLister struct {
+	client  *s3.Client
+	bucket  string
+	opts    ListContentOptions
+	workers chan struct{}
+	cancel  context.CancelFunc
+
+	count        atomic.Int64
+	budgetHit    atomic.Bool
+	emitted      atomic.Bool
+	callbackLock sync.Mutex
+}
+
+// ListS3BucketContent builds the tree of folders and objects below prefix. Sibling folders
+// are listed concurrently by up to opts.Workers goroutines. If the context is cancelled or
+// the object budget runs out, the nodes gathered so far are returned along with the error.
+func (wrapper S3Wrapper) ListS3BucketContent(ctx context.Context, bucket string, prefix string, opts ListContentOptions) ([]*shared.S3Node, error) {
+	var lister *bucketLister
+	var nodes []*shared.S3Node
+	var listErr error
+
+	// Every attempt gets a fresh lister so the object budget starts over. Once nodes have
+	// been passed to OnNode the listing isn't retried, as they would be reported twice.
+	err := wrapper.retryRequesterPays(ctx, bucket, func(client *s3.Client) error {
+		if lister != nil && lister.emitted.Load() {
+			return listErr
+		}
+
+		listCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		lister = &bucketLister{
+			client:  client,
+			bucket:  bucket,
+			opts:    opts,
+			workers: make(chan struct{}, max(opts.Workers-1, 0)),
+			cancel:  cancel,
+		}
+
+		nodes, listErr = lister.list(listCtx, prefix, 1)
+		return listErr
+	})
+
+	if lister.budgetHit.Load() {
+		return nodes, ErrObjectBudgetReached
+	}
+	if ctx.Err() != nil {
+		return nodes, ctx.Err()
+	}
+
+	return nodes, err
+}
+
+func (lister *bucketLister) list(ctx context.Context, prefix string, depth int) ([]*shared.S3Node, error) {
+	paginator := s3.NewListObjectsV2Paginator(lister.client, &s3.ListObjectsV2Input{
+		Bucket:     aws.String(lister.bucket),
+		Prefix:     aws.String(prefix),
+		Delimiter:  aws.String("/"),
+		FetchOwner: aws.Bool(true),
+	})
+
+	var nodes []*shared.S3Node
+	var wg sync.WaitGroup
+	var errLock sync.Mutex
+	var firstErr error
+
+	recordErr := func(folder string, err error) {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "AccessDenied" {
+			log.Printf("[!] Skipping forbidden folder: %s\n", folder)
+			return
+		}
+
+		errLock.Lock()
+		defer errLock.Unlock()
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			wg.Wait()
+			return nodes, err
+		}
+
+		for _, cp := range output.CommonPrefixes {
+			node := &shared.S3Node{
+				Name:     strings.TrimPrefix(*cp.Prefix, prefix),
+				Key:      *cp.Prefix,
+				IsFolder: true,
+			}
+			if !lister.emit(node, depth) {
+				wg.Wait()
+				return nodes, firstErr
+			}
+			nodes = append(nodes, node)
+
+			if lister.opts.MaxDepth > 0 && depth >= lister.opts.MaxDepth {
+				continue
+			}
+
+			select {
+			case lister.workers <- struct{}{}:
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer func() { <-lister.workers }()
+
+					children, err := lister.list(ctx, node.Key, depth+1)
+					node.Children = children
+					if err != nil {
+						recordErr(node.Key, err)
+					}
+				}()
+			default:
+				children, err := lister.list(ctx, node.Key, depth+1)
+				node.Children = children
+				if err != nil {
+					recordErr(node.Key, err)
+				}
+			}
+		}
+
+		for _, obj := range output.Contents {
+			if *obj.Key == prefix {
+				continue
+			}
+
+			node := objectNode(obj, prefix)
+			if !lister.emit(node, depth) {
+				wg.Wait()
+				return nodes, firstErr
+			}
+			nodes = append(nodes, node)
+		}
+	}
+
+	wg.Wait()
+	return nodes, firstErr
+}
+
+// emit accounts the node against the object budget and passes it to the OnNode callback.
+// It returns false once the budget is exhausted, cancelling the remaining listing.
+func (lister *bucketLister) emit(node *shared.S3Node, depth int) bool {
+	if lister.opts.MaxObjects > 0 && lister.count.Add(1) > int64(lister.opts.MaxObjects) {
+		lister.budgetHit.Store(true)
+		lister.cancel()
+		return false
+	}
+
+	lister.emitted.Store(true)
+	if lister.opts.OnNode != nil {
+		lister.callbackLock.Lock()
+		lister.opts.OnNode(node, depth)
+		lister.callbackLock.Unlock()
+	}
+
+	return true
+}
+
+func objectNode(obj types.Object, prefix string) *shared.S3Node {
+	node := &shared.S3Node{
+		Name:         strings.TrimPrefix(*obj.Key, prefix),
+		Key:          *obj.Key,
+		IsFolder:     false,
+		Size:         aws.ToInt64(obj.Size),
+		LastModified: aws.ToTime(obj.LastModified),
+		StorageClass: string(obj.StorageClass),
+		ETag:         strings.Trim(aws.ToString(obj.ETag), `"`),
+	}
+
+	if obj.Owner != nil {
+		node.Owner = aws.ToString(obj.Owner.DisplayName)
+		if node.Owner == "" {
+			node.Owner = aws.ToString(obj.Owner.ID)
+		}
+	}
+
+	return node
+}
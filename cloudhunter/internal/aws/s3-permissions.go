@@ -0,0 +1,162 @@
+package aws
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// probePolicy is deliberately not valid JSON. S3 authorizes PutBucketPolicy before
+// validating the document, so a MalformedPolicy error proves the permission without
+// ever replacing the bucket policy.
+const probePolicy = "cloudhunter-permission-probe"
+
+// ProbeBucketPermissions tests write related permissions on the bucket. The only object
+// ever written is a uniquely named marker. DeleteObject is probed without a version ID, which
+// on versioned buckets only hides the marker behind a delete marker, so both versions are then
+// deleted by version ID, which is what needs s3:DeleteObjectVersion.
+func (wrapper S3Wrapper) ProbeBucketPermissions(ctx context.Context, bucket string) shared.BucketPermissions {
+	permissions := shared.BucketPermissions{Bucket: bucket}
+	client := wrapper.BucketClient(ctx, bucket)
+	markerKey := newMarkerKey()
+
+	put, err := client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(markerKey),
+		Body:   strings.NewReader("cloudhunter permission probe marker, safe to delete\n"),
+	})
+	permissions.PutObject = classifyPermissionError(err)
+
+	if permissions.PutObject == shared.PermissionAllowed {
+		_, err = client.PutObjectAcl(ctx, &s3.PutObjectAclInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(markerKey),
+			ACL:    types.ObjectCannedACLPrivate,
+		})
+		permissions.PutObjectAcl = classifyPermissionError(err)
+	}
+
+	_, err = wrapper.GetBucketPolicy(ctx, bucket)
+	permissions.GetBucketPolicy = classifyPermissionError(err)
+
+	_, err = client.PutBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+		Bucket: aws.String(bucket),
+		Policy: aws.String(probePolicy),
+	})
+	if err == nil {
+		// Should never happen, but make it visible instead of reporting a plain success.
+		permissions.PutBucketPolicy = shared.PermissionError
+	} else {
+		permissions.PutBucketPolicy = classifyPermissionError(err)
+	}
+
+	if permissions.PutObject == shared.PermissionAllowed {
+		deleteBucketMarker(ctx, client, bucket, markerKey, aws.ToString(put.VersionId), &permissions)
+	}
+
+	return permissions
+}
+
+// deleteBucketMarker probes DeleteObject and DeleteObjectVersion on the marker and removes
+// it. Whatever can't be removed is recorded in MarkerKey and MarkerVersionIds.
+func deleteBucketMarker(ctx context.Context, client *s3.Client, bucket string, markerKey string, markerVersionId string, permissions *shared.BucketPermissions) {
+	deleted, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(markerKey),
+	})
+	permissions.DeleteObject = classifyPermissionError(err)
+
+	versioned := markerVersionId != "" && markerVersionId != "null"
+	var versionIds []string
+	switch {
+	case versioned:
+		versionIds = append(versionIds, markerVersionId)
+		if permissions.DeleteObject == shared.PermissionAllowed && aws.ToBool(deleted.DeleteMarker) {
+			versionIds = append(versionIds, aws.ToString(deleted.VersionId))
+		}
+	case permissions.DeleteObject != shared.PermissionAllowed:
+		// Objects of unversioned buckets can still be deleted by their "null" version.
+		versionIds = append(versionIds, "null")
+	default:
+		return
+	}
+
+	var leftover []string
+	for _, versionId := range versionIds {
+		_, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket:    aws.String(bucket),
+			Key:       aws.String(markerKey),
+			VersionId: aws.String(versionId),
+		})
+		if permissions.DeleteObjectVersion == shared.PermissionUntested || err != nil {
+			permissions.DeleteObjectVersion = classifyPermissionError(err)
+		}
+		if err != nil {
+			leftover = append(leftover, versionId)
+		}
+	}
+
+	if len(leftover) > 0 {
+		permissions.MarkerKey = markerKey
+		if versioned {
+			permissions.MarkerVersionIds = leftover
+		}
+	}
+}
+
+// GetBucketPolicy returns the bucket policy document, or an empty string if the bucket has none.
+func (wrapper S3Wrapper) GetBucketPolicy(ctx context.Context, bucket string) (string, error) {
+	output, err := wrapper.BucketClient(ctx, bucket).GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchBucketPolicy" {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return aws.ToString(output.Policy), nil
+}
+
+func classifyPermissionError(err error) shared.PermissionResult {
+	if err == nil {
+		return shared.PermissionAllowed
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "AccessDenied", "AllAccessDisabled":
+			return shared.PermissionDenied
+		case "AccessControlListNotSupported":
+			return shared.PermissionAclsDisabled
+		case "MalformedPolicy":
+			return shared.PermissionAllowed
+		}
+	}
+
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusForbidden {
+		return shared.PermissionDenied
+	}
+
+	return shared.PermissionError
+}
+
+func newMarkerKey() string {
+	suffix := make([]byte, 8)
+	_, _ = rand.Read(suffix)
+	return "cloudhunter-probe-" + hex.EncodeToString(suffix) + ".txt"
+}
@@ -0,0 +1,79 @@
+package aws
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// MaxPresignExpiry is the longest lifetime SigV4 allows for a presigned URL.
+const MaxPresignExpiry = 7 * 24 * time.Hour
+
+// PresignGetObject creates a presigned GET URL for the object that stays valid for expiry.
+func (wrapper S3Wrapper) PresignGetObject(ctx context.Context, bucket string, key string, expiry time.Duration) (string, error) {
+	presignClient := s3.NewPresignClient(wrapper.BucketClient(ctx, bucket))
+
+	request, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", err
+	}
+
+	return request.URL, nil
+}
+
+// ListObjects returns every object below prefix as a flat list.
+func (wrapper S3Wrapper) ListObjects(ctx context.Context, bucket string, prefix string) ([]types.Object, error) {
+	var objects []types.Object
+	err := wrapper.WalkObjects(ctx, bucket, prefix, func(page []types.Object) {
+		objects = append(objects, page...)
+	})
+
+	return objects, err
+}
+
+// WalkObjects hands the objects below prefix to fn one page at a time, so large buckets
+// don't have to be listed into memory first. Once a page was handed to fn, a failing
+// page is returned instead of being retried as requester-pays.
+func (wrapper S3Wrapper) WalkObjects(ctx context.Context, bucket string, prefix string, fn func(objects []types.Object)) error {
+	walked := false
+	var walkErr error
+
+	return wrapper.retryRequesterPays(ctx, bucket, func(client *s3.Client) error {
+		if walked {
+			return walkErr
+		}
+
+		paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+			Bucket: aws.String(bucket),
+			Prefix: aws.String(prefix),
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				walkErr = err
+				return err
+			}
+			walked = true
+			fn(page.Contents)
+		}
+		return nil
+	})
+}
+
+// CredentialsExpiry reports whether the wrapper's credentials are temporary and, if so,
+// when they expire. Presigned URLs stop working once the signing credentials expire.
+func (wrapper S3Wrapper) CredentialsExpiry(ctx context.Context) (bool, time.Time, error) {
+	credentials, err := wrapper.S3Client.Options().Credentials.Retrieve(ctx)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	temporary := credentials.SessionToken != "" || credentials.CanExpire
+	return temporary, credentials.Expires, nil
+}
@@ -0,0 +1,156 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// bucketRegionCache remembers the region of every bucket resolved during a run, the buckets
+// found to be requester-pays, and the clients built for each region and payer combination.
+type bucketRegionCache struct {
+	mu            sync.Mutex
+	byBucket      map[string]string
+	clients       map[clientKey]*s3.Client
+	requesterPays map[string]bool
+}
+
+type clientKey struct {
+	region        string
+	requesterPays bool
+}
+
+func newBucketRegionCache() *bucketRegionCache {
+	return &bucketRegionCache{
+		byBucket:      make(map[string]string),
+		clients:       make(map[clientKey]*s3.Client),
+		requesterPays: make(map[string]bool),
+	}
+}
+
+func (cache *bucketRegionCache) region(bucket string) (string, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	region, ok := cache.byBucket[bucket]
+	return region, ok
+}
+
+func (cache *bucketRegionCache) setRegion(bucket string, region string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.byBucket[bucket] = region
+}
+
+func (cache *bucketRegionCache) client(base *s3.Client, key clientKey, expectedOwner string) *s3.Client {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if client, ok := cache.clients[key]; ok {
+		return client
+	}
+
+	client := s3.New(base.Options(), func(o *s3.Options) {
+		o.Region = key.region
+		if key.requesterPays {
+			o.APIOptions = append(o.APIOptions, smithyhttp.SetHeaderValue("x-amz-request-payer", "requester"))
+		}
+		if expectedOwner != "" {
+			o.APIOptions = append(o.APIOptions, smithyhttp.SetHeaderValue("x-amz-expected-bucket-owner", expectedOwner))
+		}
+	})
+	cache.clients[key] = client
+
+	return client
+}
+
+// BucketClient returns a client configured for the region the bucket lives in, sending
+// the requester-pays and expected-owner headers when they apply to the bucket.
+// If the region cannot be resolved the client's default region is used.
+func (wrapper S3Wrapper) BucketClient(ctx context.Context, bucket string) *s3.Client {
+	region, err := wrapper.ResolveBucketRegion(ctx, bucket)
+	if err != nil {
+		log.Printf("[!] Couldn't resolve region of bucket %s, using %s: %v", bucket, wrapper.S3Client.Options().Region, err)
+		region = wrapper.S3Client.Options().Region
+	}
+
+	key := clientKey{
+		region:        region,
+		requesterPays: wrapper.RequesterPays || wrapper.regions.isRequesterPays(bucket),
+	}
+	if key.region == wrapper.S3Client.Options().Region && !key.requesterPays && wrapper.ExpectedOwner == "" {
+		return wrapper.S3Client
+	}
+
+	return wrapper.regions.client(wrapper.S3Client, key, wrapper.ExpectedOwner)
+}
+
+// ResolveBucketRegion returns the region of the bucket. Access point ARNs carry their region.
+// Bucket names are resolved with HeadBucket, whose x-amz-bucket-region header is also read
+// when the request is redirected or denied, falling back to GetBucketLocation. Resolved
+// bucket regions are cached for the rest of the run.
+func (wrapper S3Wrapper) ResolveBucketRegion(ctx context.Context, bucket string) (string, error) {
+	if region, ok := wrapper.regions.region(bucket); ok {
+		return region, nil
+	}
+
+	// Access point ARNs carry their region. Multi-Region Access Points have none
+	// and are signed with SigV4A, so the client's region is kept for them.
+	if arn.IsARN(bucket) {
+		parsed, err := arn.Parse(bucket)
+		if err != nil {
+			return "", err
+		}
+		if parsed.Region == "" {
+			return wrapper.S3Client.Options().Region, nil
+		}
+		return parsed.Region, nil
+	}
+
+	output, err := wrapper.S3Client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(bucket),
+	}, probeOptions(bucket, wrapper.S3Client.Options().Region))
+	if err == nil && output.BucketRegion != nil {
+		wrapper.regions.setRegion(bucket, *output.BucketRegion)
+		return *output.BucketRegion, nil
+	}
+
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) {
+		if region := respErr.Response.Header.Get("x-amz-bucket-region"); region != "" {
+			wrapper.regions.setRegion(bucket, region)
+			return region, nil
+		}
+	}
+
+	location, locationErr := wrapper.S3Client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{
+		Bucket: aws.String(bucket),
+	})
+	if locationErr != nil {
+		if err != nil {
+			return "", err
+		}
+		return "", locationErr
+	}
+
+	// Buckets in us-east-1 report an empty location constraint and
+	// legacy buckets in eu-west-1 report "EU".
+	region := string(location.LocationConstraint)
+	switch region {
+	case "":
+		region = "us-east-1"
+	case "EU":
+		region = "eu-west-1"
+	}
+
+	wrapper.regions.setRegion(bucket, region)
+	return region, nil
+}
@@ -0,0 +1,66 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+func (cache *bucketRegionCache) isRequesterPays(bucket string) bool {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	return cache.requesterPays[bucket]
+}
+
+func (cache *bucketRegionCache) setRequesterPays(bucket string, requesterPays bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if requesterPays {
+		cache.requesterPays[bucket] = true
+	} else {
+		delete(cache.requesterPays, bucket)
+	}
+}
+
+// RequesterPaysBuckets returns the buckets that were only accessible after automatically
+// retrying with RequestPayer=requester.
+func (wrapper S3Wrapper) RequesterPaysBuckets() []string {
+	wrapper.regions.mu.Lock()
+	defer wrapper.regions.mu.Unlock()
+
+	var buckets []string
+	for bucket := range wrapper.regions.requesterPays {
+		buckets = append(buckets, bucket)
+	}
+	sort.Strings(buckets)
+
+	return buckets
+}
+
+// retryRequesterPays runs fn with the bucket's client. Requester-pays buckets deny requests
+// that don't acknowledge the charges, so on AccessDenied fn is retried once with
+// RequestPayer=requester and the bucket is remembered if that succeeds.
+func (wrapper S3Wrapper) retryRequesterPays(ctx context.Context, bucket string, fn func(client *s3.Client) error) error {
+	err := fn(wrapper.BucketClient(ctx, bucket))
+	if err == nil || wrapper.RequesterPays || wrapper.regions.isRequesterPays(bucket) {
+		return err
+	}
+
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "AccessDenied" {
+		return err
+	}
+
+	wrapper.regions.setRequesterPays(bucket, true)
+	if retryErr := fn(wrapper.BucketClient(ctx, bucket)); retryErr != nil {
+		wrapper.regions.setRequesterPays(bucket, false)
+		return err
+	}
+
+	return nil
+}
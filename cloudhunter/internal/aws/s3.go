@@ -3,14 +3,19 @@ package aws
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/Kimi99/cloudhunter/internal/shared"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/smithy-go"
@@ -18,82 +23,37 @@ import (
 
 // S3Wrapper encapsulates the Amazon Simple Storage Service (Amazon S3) actions.
 // It contains S3Client, an Amazon S3 service client that is used to perform bucket and object actions.
+// Buckets living outside of the client's region are transparently served by a client
+// rebuilt for the bucket's region, see BucketClient.
+// RequesterPays and ExpectedOwner are sent with every bucket level request when set.
 type S3Wrapper struct {
-	S3Client *s3.Client
+	S3Client      *s3.Client
+	RequesterPays bool
+	ExpectedOwner string
+	regions       *bucketRegionCache
 }
 
 func InitializeS3Wrapper(ctx context.Context, region string, profile string, anonymousMode bool) S3Wrapper {
-	if anonymousMode {
-		var client = s3.New(s3.Options{
-			Credentials: aws.AnonymousCredentials{},
-			Region:      region,
-		})
+	var cfg aws.Config
+	var err error
 
-		return S3Wrapper{S3Client: client}
+	if anonymousMode {
+		cfg, err = shared.GetAnonymousAWSConfig(ctx, region)
+	} else {
+		cfg, err = shared.GetAWSConfig(ctx, region, profile)
 	}
-
-	cfg, err := shared.GetAWSConfig(ctx, region, profile)
 	if err != nil {
-		log.Fatal(err)
+		shared.Fatal(err)
 	}
 
-	client := s3.NewFromConfig(cfg)
-	return S3Wrapper{S3Client: client}
-}
-
-func (wrapper S3Wrapper) ListS3BucketContent(ctx context.Context, bucket string, prefix string) ([]*shared.S3Node, error) {
-	input := &s3.ListObjectsV2Input{
-		Bucket:    aws.String(bucket),
-		Prefix:    aws.String(prefix),
-		Delimiter: aws.String("/"),
-	}
-
-	paginator := s3.NewListObjectsV2Paginator(wrapper.S3Client, input)
-
-	var nodes []*shared.S3Node
-
-	for paginator.HasMorePages() {
-		output, err := paginator.NextPage(ctx)
-		if err != nil {
-			return nil, err
-		}
-
-		for _, cp := range output.CommonPrefixes {
-			name := strings.TrimPrefix(*cp.Prefix, prefix)
-			node := &shared.S3Node{
-				Name:     name,
-				IsFolder: true,
-			}
-
-			childNodes, err := wrapper.ListS3BucketContent(ctx, bucket, *cp.Prefix)
-			if err != nil {
-				var apiErr smithy.APIError
-				if errors.As(err, &apiErr) && apiErr.ErrorCode() == "AccessDenied" {
-					log.Printf("[!] Skipping forbidden folder: %s\n", *cp.Prefix)
-					nodes = append(nodes, node)
-					continue
-				}
-				return nil, err
-			}
-			node.Children = childNodes
-			nodes = append(nodes, node)
-		}
-
-		for _, obj := range output.Contents {
-			if *obj.Key == prefix {
-				continue
-			}
-
-			name := strings.TrimPrefix(*obj.Key, prefix)
-			node := &shared.S3Node{
-				Name:     name,
-				IsFolder: false,
-			}
-			nodes = append(nodes, node)
-		}
+	if cfg.Region == "" {
+		cfg.Region = shared.DefaultRegion
 	}
 
-	return nodes, nil
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = shared.S3PathStyle
+	})
+	return S3Wrapper{S3Client: client, regions: newBucketRegionCache()}
 }
 
 func (wrapper S3Wrapper) ListBuckets(ctx context.Context) ([]types.Bucket, error) {
@@ -112,6 +72,11 @@ func (wrapper S3Wrapper) ListBuckets(ctx context.Context) ([]types.Bucket, error
 			}
 			break
 		} else {
+			for _, bucket := range output.Buckets {
+				if bucket.Name != nil && bucket.BucketRegion != nil {
+					wrapper.regions.setRegion(*bucket.Name, *bucket.BucketRegion)
+				}
+			}
 			buckets = append(buckets, output.Buckets...)
 		}
 	}
@@ -119,7 +84,26 @@ func (wrapper S3Wrapper) ListBuckets(ctx context.Context) ([]types.Bucket, error
 }
 
 func (wrapper S3Wrapper) DumpBucketWrapper(ctx context.Context, bucketName string, localFolder string) error {
-	paginator := s3.NewListObjectsV2Paginator(wrapper.S3Client, &s3.ListObjectsV2Input{
+	var client *s3.Client
+	var firstPage *s3.ListObjectsV2Output
+	err := wrapper.retryRequesterPays(ctx, bucketName, func(bucketClient *s3.Client) error {
+		var err error
+		client = bucketClient
+		firstPage, err = client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:  aws.String(bucketName),
+			MaxKeys: aws.Int32(1),
+		})
+		return err
+	})
+	if err != nil {
+		log.Printf("Error listing objects in bucket %s: %v", bucketName, err)
+		return err
+	}
+	if len(firstPage.Contents) == 0 {
+		return nil
+	}
+
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
 		Bucket: aws.String(bucketName),
 	})
 
@@ -131,48 +115,311 @@ func (wrapper S3Wrapper) DumpBucketWrapper(ctx context.Context, bucketName strin
 		}
 
 		for _, obj := range page.Contents {
-			key := *obj.Key
-
-			if strings.HasSuffix(key, "/") {
+			if strings.HasSuffix(*obj.Key, "/") {
 				continue
 			}
 
-			localPath := filepath.Join(localFolder, key)
-
-			if err := os.MkdirAll(filepath.Dir(localPath), os.ModePerm); err != nil {
-				log.Printf("Couldn't create directory for %s: %v", localPath, err)
-				return err
+			err := wrapper.DownloadObject(ctx, bucketName, *obj.Key, localFolder)
+			if errors.Is(err, ErrUnsafeObjectKey) {
+				// Already logged, the rest of the bucket is still worth having.
+				continue
 			}
-
-			log.Printf("[+] Downloading: s3://%s/%s", bucketName, key)
-			resp, err := wrapper.S3Client.GetObject(ctx, &s3.GetObjectInput{
-				Bucket: aws.String(bucketName),
-				Key:    aws.String(key),
-			})
 			if err != nil {
-				var noKey *types.NoSuchKey
-				if errors.As(err, &noKey) {
-					log.Printf("Key does not exist: %s", key)
-					return err
-				}
-				log.Printf("Error getting object %s: %v", key, err)
 				return err
 			}
-			defer resp.Body.Close()
+		}
+	}
 
-			outFile, err := os.Create(localPath)
-			if err != nil {
-				log.Printf("Couldn't create local file %s: %v", localPath, err)
-				return err
+	return nil
+}
+
+// ErrUnsafeObjectKey is returned by DownloadObject for keys, such as "../x", that would be
+// stored outside of the local folder.
+var ErrUnsafeObjectKey = errors.New("object key can't be stored safely")
+
+// DownloadObject stores the object below localFolder, keeping its key as the relative path.
+func (wrapper S3Wrapper) DownloadObject(ctx context.Context, bucketName string, key string, localFolder string) error {
+	localPath, err := shared.ContainedPath(localFolder, key)
+	if err != nil {
+		log.Printf("Refusing to download %s: %v", key, err)
+		return fmt.Errorf("%w: %v", ErrUnsafeObjectKey, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(localPath), os.ModePerm); err != nil {
+		log.Printf("Couldn't create directory for %s: %v", localPath, err)
+		return err
+	}
+
+	log.Printf("[+] Downloading: s3://%s/%s", bucketName, key)
+	resp, err := wrapper.BucketClient(ctx, bucketName).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noKey *types.NoSuchKey
+		if errors.As(err, &noKey) {
+			log.Printf("Key does not exist: %s", key)
+			return err
+		}
+		log.Printf("Error getting object %s: %v", key, err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	outFile, err := os.Create(localPath)
+	if err != nil {
+		log.Printf("Couldn't create local file %s: %v", localPath, err)
+		return err
+	}
+	defer outFile.Close()
+
+	_, err = io.Copy(outFile, resp.Body)
+	if err != nil {
+		log.Printf("Failed writing to file %s: %v", localPath, err)
+	}
+
+	return nil
+}
+
+// ProbeBucket checks whether the bucket exists and how much of it is exposed to the
+// current credentials, following the x-amz-bucket-region header to the bucket's region.
+func (wrapper S3Wrapper) ProbeBucket(ctx context.Context, bucket string) (shared.BucketDiscoveryResult, error) {
+	result := shared.BucketDiscoveryResult{Name: bucket, Status: shared.BucketNotFound}
+	region := wrapper.S3Client.Options().Region
+
+	for attempt := 0; attempt < 2; attempt++ {
+		output, err := wrapper.S3Client.HeadBucket(ctx, &s3.HeadBucketInput{
+			Bucket: aws.String(bucket),
+		}, probeOptions(bucket, region))
+		if err == nil {
+			if output.BucketRegion != nil {
+				region = *output.BucketRegion
 			}
-			defer outFile.Close()
+			result.Region = region
+			result.Status = shared.BucketPrivate
+			break
+		}
 
-			_, err = io.Copy(outFile, resp.Body)
-			if err != nil {
-				log.Printf("Failed writing to file %s: %v", localPath, err)
+		var respErr *awshttp.ResponseError
+		if !errors.As(err, &respErr) {
+			return result, err
+		}
+
+		if headerRegion := respErr.Response.Header.Get("x-amz-bucket-region"); headerRegion != "" {
+			region = headerRegion
+		}
+		result.Region = region
+
+		switch respErr.HTTPStatusCode() {
+		case http.StatusNotFound:
+			return result, nil
+		case http.StatusForbidden:
+			result.Status = shared.BucketPrivate
+			wrapper.regions.setRegion(bucket, region)
+			return result, nil
+		case http.StatusMovedPermanently, http.StatusBadRequest:
+			if attempt == 0 && region != wrapper.S3Client.Options().Region {
+				continue
 			}
 		}
+
+		return result, err
 	}
 
-	return nil
+	if result.Status == shared.BucketNotFound {
+		return result, nil
+	}
+	wrapper.regions.setRegion(bucket, region)
+
+	listing, err := wrapper.S3Client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(bucket),
+		MaxKeys: aws.Int32(1),
+	}, probeOptions(bucket, region))
+	if err != nil {
+		return result, nil
+	}
+	result.Status = shared.BucketListable
+
+	if len(listing.Contents) == 0 {
+		return result, nil
+	}
+
+	object, err := wrapper.S3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    listing.Contents[0].Key,
+		Range:  aws.String("bytes=0-0"),
+	}, probeOptions(bucket, region))
+	if err != nil {
+		return result, nil
+	}
+	object.Body.Close()
+	result.Status = shared.BucketReadable
+
+	return result, nil
+}
+
+// DiscoverBuckets probes every candidate bucket name using a bounded pool of workers
+// and reports each result through onResult as soon as it is available.
+func (wrapper S3Wrapper) DiscoverBuckets(ctx context.Context, candidates []string, workers int, onResult func(shared.BucketDiscoveryResult, error)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for bucket := range jobs {
+				result, err := wrapper.ProbeBucket(ctx, bucket)
+				mu.Lock()
+				onResult(result, err)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, candidate := range candidates {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- candidate
+	}
+	close(jobs)
+
+	wg.Wait()
+}
+
+// probeOptions points a single request at the given region. Bucket names containing dots
+// break virtual-hosted TLS certificates, so those are addressed path-style instead.
+func probeOptions(bucket string, region string) func(*s3.Options) {
+	return func(o *s3.Options) {
+		o.Region = region
+		if strings.Contains(bucket, ".") {
+			o.UsePathStyle = true
+		}
+	}
+}
+
+// GetObjectMetadata collects user metadata, encryption, object lock status and tags of a single object.
+func (wrapper S3Wrapper) GetObjectMetadata(ctx context.Context, bucket string, key string) (*shared.S3ObjectMetadata, error) {
+	client := wrapper.BucketClient(ctx, bucket)
+
+	head, err := client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &shared.S3ObjectMetadata{
+		UserMetadata:         head.Metadata,
+		ServerSideEncryption: string(head.ServerSideEncryption),
+		KMSKeyId:             aws.ToString(head.SSEKMSKeyId),
+		ObjectLockMode:       string(head.ObjectLockMode),
+		ObjectLockRetainDate: head.ObjectLockRetainUntilDate,
+		ObjectLockLegalHold:  string(head.ObjectLockLegalHoldStatus),
+	}
+
+	tagging, err := client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		log.Printf("[!] Couldn't retrieve tags of s3://%s/%s: %v", bucket, key, err)
+		return metadata, nil
+	}
+
+	metadata.Tags = make(map[string]string)
+	for _, tag := range tagging.TagSet {
+		metadata.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	return metadata, nil
+}
+
+// PopulateObjectMetadata walks the tree and attaches metadata to every object node.
+// Objects whose metadata cannot be read are logged and skipped.
+func (wrapper S3Wrapper) PopulateObjectMetadata(ctx context.Context, bucket string, nodes []*shared.S3Node) {
+	for _, node := range nodes {
+		if node.IsFolder {
+			wrapper.PopulateObjectMetadata(ctx, bucket, node.Children)
+			continue
+		}
+
+		metadata, err := wrapper.GetObjectMetadata(ctx, bucket, node.Key)
+		if err != nil {
+			log.Printf("[!] Couldn't retrieve metadata of s3://%s/%s: %v", bucket, node.Key, err)
+			continue
+		}
+		node.Metadata = metadata
+	}
+}
+
+// GetObjectStream opens the object for reading. byteRange uses the HTTP Range syntax,
+// e.g. "bytes=0-1023"; an empty range fetches the whole object.
+func (wrapper S3Wrapper) GetObjectStream(ctx context.Context, bucket string, key string, byteRange string) (io.ReadCloser, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if byteRange != "" {
+		input.Range = aws.String(byteRange)
+	}
+
+	var body io.ReadCloser
+	err := wrapper.retryRequesterPays(ctx, bucket, func(client *s3.Client) error {
+		output, err := client.GetObject(ctx, input)
+		if err != nil {
+			return err
+		}
+		body = output.Body
+		return nil
+	})
+
+	return body, err
+}
+
+// TakeSnapshot records the key, size, ETag and last modified date of every object in the bucket.
+func (wrapper S3Wrapper) TakeSnapshot(ctx context.Context, bucket string) (shared.BucketSnapshot, error) {
+	snapshot := shared.BucketSnapshot{Bucket: bucket, TakenAt: time.Now().UTC()}
+
+	objects, err := wrapper.ListObjects(ctx, bucket, "")
+	if err != nil {
+		return snapshot, err
+	}
+
+	for _, object := range objects {
+		snapshot.Objects = append(snapshot.Objects, shared.SnapshotObject{
+			Key:          aws.ToString(object.Key),
+			Size:         aws.ToInt64(object.Size),
+			ETag:         strings.Trim(aws.ToString(object.ETag), `"`),
+			LastModified: aws.ToTime(object.LastModified).UTC(),
+		})
+	}
+
+	return snapshot, nil
+}
+
+// ReadObject downloads the whole object into memory, refusing objects larger than maxSize bytes.
+func (wrapper S3Wrapper) ReadObject(ctx context.Context, bucket string, key string, maxSize int64) ([]byte, error) {
+	body, err := wrapper.GetObjectStream(ctx, bucket, key, "")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("object s3://%s/%s is larger than %s", bucket, key, shared.FormatBytes(maxSize))
+	}
+
+	return data, nil
 }
@@ -0,0 +1,212 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3control"
+	"github.com/aws/smithy-go"
+)
+
+// Multi-Region Access Point control plane requests are only served from us-west-2.
+const multiRegionAccessPointRegion = "us-west-2"
+
+// S3ControlWrapper encapsulates the Amazon S3 Control actions used to enumerate access points.
+// Every S3 Control request is scoped to an account, so AccountId is resolved on initialization.
+type S3ControlWrapper struct {
+	S3ControlClient *s3control.Client
+	AccountId       string
+}
+
+func InitializeS3ControlWrapper(ctx context.Context, region string, profile string) S3ControlWrapper {
+	cfg, err := shared.GetAWSConfig(ctx, region, profile)
+	if err != nil {
+		shared.Fatal(err)
+	}
+
+	identity, err := InitializeStsWrapper(ctx, region, profile).GetCallerIdentityWrapper(ctx)
+	if err != nil {
+		shared.Fatal(err)
+	}
+
+	client := s3control.NewFromConfig(cfg)
+	return S3ControlWrapper{S3ControlClient: client, AccountId: aws.ToString(identity.Account)}
+}
+
+// ListAccessPointsWrapper lists the access points in the given region along with their policies.
+func (wrapper S3ControlWrapper) ListAccessPointsWrapper(ctx context.Context, region string) ([]shared.AccessPoint, error) {
+	if region == "" {
+		region = wrapper.S3ControlClient.Options().Region
+	}
+
+	paginator := s3control.NewListAccessPointsPaginator(wrapper.S3ControlClient, &s3control.ListAccessPointsInput{
+		AccountId: aws.String(wrapper.AccountId),
+	})
+
+	var accessPoints []shared.AccessPoint
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx, inRegion(region))
+		if err != nil {
+			return accessPoints, err
+		}
+
+		for _, accessPoint := range page.AccessPointList {
+			info := shared.AccessPoint{
+				Kind:          "access point",
+				Name:          aws.ToString(accessPoint.Name),
+				Arn:           aws.ToString(accessPoint.AccessPointArn),
+				Alias:         aws.ToString(accessPoint.Alias),
+				Bucket:        aws.ToString(accessPoint.Bucket),
+				Region:        region,
+				NetworkOrigin: string(accessPoint.NetworkOrigin),
+			}
+			if accessPoint.VpcConfiguration != nil {
+				info.VpcId = aws.ToString(accessPoint.VpcConfiguration.VpcId)
+			}
+
+			policy, err := wrapper.S3ControlClient.GetAccessPointPolicy(ctx, &s3control.GetAccessPointPolicyInput{
+				AccountId: aws.String(wrapper.AccountId),
+				Name:      accessPoint.Name,
+			}, inRegion(region))
+			var document *string
+			if err == nil {
+				document = policy.Policy
+			}
+			info.Policy = policyOrNote(aws.ToString(document), err)
+
+			accessPoints = append(accessPoints, info)
+		}
+	}
+
+	return accessPoints, nil
+}
+
+// ListObjectLambdaAccessPointsWrapper lists the Object Lambda access points in the given region along with their policies.
+func (wrapper S3ControlWrapper) ListObjectLambdaAccessPointsWrapper(ctx context.Context, region string) ([]shared.AccessPoint, error) {
+	if region == "" {
+		region = wrapper.S3ControlClient.Options().Region
+	}
+
+	paginator := s3control.NewListAccessPointsForObjectLambdaPaginator(wrapper.S3ControlClient, &s3control.ListAccessPointsForObjectLambdaInput{
+		AccountId: aws.String(wrapper.AccountId),
+	})
+
+	var accessPoints []shared.AccessPoint
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx, inRegion(region))
+		if err != nil {
+			return accessPoints, err
+		}
+
+		for _, accessPoint := range page.ObjectLambdaAccessPointList {
+			info := shared.AccessPoint{
+				Kind:   "object lambda access point",
+				Name:   aws.ToString(accessPoint.Name),
+				Arn:    aws.ToString(accessPoint.ObjectLambdaAccessPointArn),
+				Region: region,
+			}
+			if accessPoint.Alias != nil {
+				info.Alias = aws.ToString(accessPoint.Alias.Value)
+			}
+
+			policy, err := wrapper.S3ControlClient.GetAccessPointPolicyForObjectLambda(ctx, &s3control.GetAccessPointPolicyForObjectLambdaInput{
+				AccountId: aws.String(wrapper.AccountId),
+				Name:      accessPoint.Name,
+			}, inRegion(region))
+			var document *string
+			if err == nil {
+				document = policy.Policy
+			}
+			info.Policy = policyOrNote(aws.ToString(document), err)
+
+			accessPoints = append(accessPoints, info)
+		}
+	}
+
+	return accessPoints, nil
+}
+
+// ListMultiRegionAccessPointsWrapper lists the Multi-Region Access Points of the account along with their policies.
+func (wrapper S3ControlWrapper) ListMultiRegionAccessPointsWrapper(ctx context.Context) ([]shared.AccessPoint, error) {
+	paginator := s3control.NewListMultiRegionAccessPointsPaginator(wrapper.S3ControlClient, &s3control.ListMultiRegionAccessPointsInput{
+		AccountId: aws.String(wrapper.AccountId),
+	})
+
+	var accessPoints []shared.AccessPoint
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx, inRegion(multiRegionAccessPointRegion))
+		if err != nil {
+			return accessPoints, err
+		}
+
+		for _, accessPoint := range page.AccessPoints {
+			var buckets, regions []string
+			for _, regionReport := range accessPoint.Regions {
+				buckets = append(buckets, aws.ToString(regionReport.Bucket))
+				regions = append(regions, aws.ToString(regionReport.Region))
+			}
+
+			info := shared.AccessPoint{
+				Kind:   "multi-region access point",
+				Name:   aws.ToString(accessPoint.Name),
+				Arn:    fmt.Sprintf("arn:aws:s3::%s:accesspoint/%s", wrapper.AccountId, aws.ToString(accessPoint.Alias)),
+				Alias:  aws.ToString(accessPoint.Alias),
+				Bucket: strings.Join(buckets, ","),
+				Region: strings.Join(regions, ","),
+			}
+
+			policy, err := wrapper.S3ControlClient.GetMultiRegionAccessPointPolicy(ctx, &s3control.GetMultiRegionAccessPointPolicyInput{
+				AccountId: aws.String(wrapper.AccountId),
+				Name:      accessPoint.Name,
+			}, inRegion(multiRegionAccessPointRegion))
+			switch {
+			case err != nil || policy.Policy == nil:
+				info.Policy = policyOrNote("", err)
+			case policy.Policy.Established != nil:
+				info.Policy = policyOrNote(aws.ToString(policy.Policy.Established.Policy), nil)
+			case policy.Policy.Proposed != nil:
+				// Policy changes take a while to propagate to every region of the access point.
+				info.Policy = "(proposed, not yet established)\n" + policyOrNote(aws.ToString(policy.Policy.Proposed.Policy), nil)
+			default:
+				info.Policy = policyOrNote("", nil)
+			}
+
+			accessPoints = append(accessPoints, info)
+		}
+	}
+
+	return accessPoints, nil
+}
+
+func inRegion(region string) func(*s3control.Options) {
+	return func(o *s3control.Options) {
+		if region != "" {
+			o.Region = region
+		}
+	}
+}
+
+// policyOrNote returns the policy document, or a short note explaining why there is none.
+func policyOrNote(policy string, err error) string {
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && strings.HasPrefix(apiErr.ErrorCode(), "NoSuch") {
+			return "(no policy)"
+		}
+		return fmt.Sprintf("(couldn't retrieve policy: %v)", err)
+	}
+	if policy == "" {
+		return "(no policy)"
+	}
+
+	indented, err := shared.IndentJsonDocument(policy)
+	if err != nil {
+		return fmt.Sprintf("(couldn't parse policy: %v)\n%s", err, policy)
+	}
+
+	return indented
+}
@@ -0,0 +1,80 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+)
+
+// SecretsManagerWrapper encapsulates AWS Secrets Manager actions.
+// It contains SecretsManagerClient, a Secrets Manager service client bound to a single region.
+type SecretsManagerWrapper struct {
+	SecretsManagerClient *secretsmanager.Client
+}
+
+func InitializeSecretsManagerWrapper(ctx context.Context, region string, profile string) SecretsManagerWrapper {
+	cfg, err := shared.GetAWSConfig(ctx, region, profile)
+	if err != nil {
+		shared.Fatal(err)
+	}
+
+	client := secretsmanager.NewFromConfig(cfg)
+	return SecretsManagerWrapper{SecretsManagerClient: client}
+}
+
+func (wrapper SecretsManagerWrapper) ListSecretsWrapper(ctx context.Context) ([]types.SecretListEntry, error) {
+	paginator := secretsmanager.NewListSecretsPaginator(wrapper.SecretsManagerClient, &secretsmanager.ListSecretsInput{})
+
+	var secrets []types.SecretListEntry
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return secrets, err
+		}
+		secrets = append(secrets, page.SecretList...)
+	}
+
+	return secrets, nil
+}
+
+// GetResourcePolicyWrapper returns the resource policy of the secret, or an empty string if it has none.
+func (wrapper SecretsManagerWrapper) GetResourcePolicyWrapper(ctx context.Context, secretId string) (string, error) {
+	output, err := wrapper.SecretsManagerClient.GetResourcePolicy(ctx, &secretsmanager.GetResourcePolicyInput{
+		SecretId: aws.String(secretId),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return aws.ToString(output.ResourcePolicy), nil
+}
+
+// ListSecretVersionsWrapper returns every version of the secret, including deprecated
+// versions that no longer carry a staging label.
+func (wrapper SecretsManagerWrapper) ListSecretVersionsWrapper(ctx context.Context, secretId string) ([]types.SecretVersionsListEntry, error) {
+	paginator := secretsmanager.NewListSecretVersionIdsPaginator(wrapper.SecretsManagerClient, &secretsmanager.ListSecretVersionIdsInput{
+		SecretId:          aws.String(secretId),
+		IncludeDeprecated: aws.Bool(true),
+	})
+
+	var versions []types.SecretVersionsListEntry
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return versions, err
+		}
+		versions = append(versions, page.Versions...)
+	}
+
+	return versions, nil
+}
+
+func (wrapper SecretsManagerWrapper) GetSecretValueWrapper(ctx context.Context, secretId string, versionId string) (*secretsmanager.GetSecretValueOutput, error) {
+	return wrapper.SecretsManagerClient.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId:  aws.String(secretId),
+		VersionId: aws.String(versionId),
+	})
+}
@@ -0,0 +1,140 @@
+package aws
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// SsmWrapper encapsulates AWS Systems Manager actions.
+// It contains SsmClient, a Systems Manager service client bound to a single region.
+type SsmWrapper struct {
+	SsmClient *ssm.Client
+}
+
+func InitializeSsmWrapper(ctx context.Context, region string, profile string) SsmWrapper {
+	cfg, err := shared.GetAWSConfig(ctx, region, profile)
+	if err != nil {
+		shared.Fatal(err)
+	}
+
+	client := ssm.NewFromConfig(cfg)
+	return SsmWrapper{SsmClient: client}
+}
+
+// DescribeParametersWrapper returns the metadata of every parameter, without their values.
+func (wrapper SsmWrapper) DescribeParametersWrapper(ctx context.Context) ([]types.ParameterMetadata, error) {
+	paginator := ssm.NewDescribeParametersPaginator(wrapper.SsmClient, &ssm.DescribeParametersInput{})
+
+	var parameters []types.ParameterMetadata
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return parameters, err
+		}
+		parameters = append(parameters, page.Parameters...)
+	}
+
+	return parameters, nil
+}
+
+// GetParametersByPathWrapper returns the parameters below the path, recursively. SecureString
+// values are decrypted when decrypt is set, which needs kms:Decrypt on the parameter's key.
+func (wrapper SsmWrapper) GetParametersByPathWrapper(ctx context.Context, path string, decrypt bool) ([]types.Parameter, error) {
+	paginator := ssm.NewGetParametersByPathPaginator(wrapper.SsmClient, &ssm.GetParametersByPathInput{
+		Path:           aws.String(path),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(decrypt),
+	})
+
+	var parameters []types.Parameter
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return parameters, err
+		}
+		parameters = append(parameters, page.Parameters...)
+	}
+
+	return parameters, nil
+}
+
+// GetParametersWrapper returns the named parameters. GetParameters takes at most 10 names per
+// call, so the names are requested in batches. Names that don't exist are skipped.
+func (wrapper SsmWrapper) GetParametersWrapper(ctx context.Context, names []string, decrypt bool) ([]types.Parameter, error) {
+	var parameters []types.Parameter
+	for batch := range slices.Chunk(names, 10) {
+		output, err := wrapper.SsmClient.GetParameters(ctx, &ssm.GetParametersInput{
+			Names:          batch,
+			WithDecryption: aws.Bool(decrypt),
+		})
+		if err != nil {
+			return parameters, err
+		}
+		parameters = append(parameters, output.Parameters...)
+	}
+
+	return parameters, nil
+}
+
+// DescribeInstanceInformationWrapper returns the instances and hybrid nodes registered with SSM.
+func (wrapper SsmWrapper) DescribeInstanceInformationWrapper(ctx context.Context) ([]types.InstanceInformation, error) {
+	paginator := ssm.NewDescribeInstanceInformationPaginator(wrapper.SsmClient, &ssm.DescribeInstanceInformationInput{})
+
+	var instances []types.InstanceInformation
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return instances, err
+		}
+		instances = append(instances, page.InstanceInformationList...)
+	}
+
+	return instances, nil
+}
+
+// ListOwnedDocumentsWrapper returns the documents owned by the caller's account.
+func (wrapper SsmWrapper) ListOwnedDocumentsWrapper(ctx context.Context) ([]types.DocumentIdentifier, error) {
+	paginator := ssm.NewListDocumentsPaginator(wrapper.SsmClient, &ssm.ListDocumentsInput{
+		Filters: []types.DocumentKeyValuesFilter{
+			{Key: aws.String("Owner"), Values: []string{"Self"}},
+		},
+	})
+
+	var documents []types.DocumentIdentifier
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return documents, err
+		}
+		documents = append(documents, page.DocumentIdentifiers...)
+	}
+
+	return documents, nil
+}
+
+// GetDocumentSharingWrapper reads the share permission of the document.
+func (wrapper SsmWrapper) GetDocumentSharingWrapper(ctx context.Context, documentName string) (shared.ResourceSharing, error) {
+	output, err := wrapper.SsmClient.DescribeDocumentPermission(ctx, &ssm.DescribeDocumentPermissionInput{
+		Name:           aws.String(documentName),
+		PermissionType: types.DocumentPermissionTypeShare,
+	})
+	if err != nil {
+		return shared.UnknownSharing(err), err
+	}
+
+	var sharing shared.ResourceSharing
+	for _, account := range output.AccountIds {
+		if account == PublicGroup {
+			sharing.Public = true
+		} else {
+			sharing.Accounts = append(sharing.Accounts, account)
+		}
+	}
+
+	return sharing, nil
+}
@@ -0,0 +1,28 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/Kimi99/cloudhunter/internal/shared"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// StsWrapper encapsulates AWS Security Token Service actions.
+// It is mostly used to find out which principal and account the credentials belong to.
+type StsWrapper struct {
+	StsClient *sts.Client
+}
+
+func InitializeStsWrapper(ctx context.Context, region string, profile string) StsWrapper {
+	cfg, err := shared.GetAWSConfig(ctx, region, profile)
+	if err != nil {
+		shared.Fatal(err)
+	}
+
+	client := sts.NewFromConfig(cfg)
+	return StsWrapper{StsClient: client}
+}
+
+func (wrapper StsWrapper) GetCallerIdentityWrapper(ctx context.Context) (*sts.GetCallerIdentityOutput, error) {
+	return wrapper.StsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+}
@@ -0,0 +1,102 @@
+package shared
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+)
+
+// KnownAccount labels an AWS account owned by AWS itself or a vendor.
+type KnownAccount struct {
+	Id          string `json:"id"`
+	Owner       string `json:"owner"`
+	Description string `json:"description"`
+}
+
+func (account KnownAccount) String() string {
+	return fmt.Sprintf("%s (%s)", account.Owner, account.Description)
+}
+
+// knownAccountsData is the bundled list, taken from public lists of AWS service and vendor
+// accounts. Update known-accounts.json, or pass extra entries with --known-accounts.
+//
+//go:embed known-accounts.json
+var knownAccountsData []byte
+
+// LoadKnownAccounts returns the bundled known accounts keyed by ID, extended and overridden
+// by the entries of the file at path, which has the same format, when path isn't empty.
+func LoadKnownAccounts(path string) (map[string]KnownAccount, error) {
+	accounts := make(map[string]KnownAccount)
+	if err := addKnownAccounts(accounts, knownAccountsData); err != nil {
+		return nil, fmt.Errorf("[-] Failed to parse bundled known accounts: %w", err)
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("[-] Failed to read known accounts: %w", err)
+		}
+		if err := addKnownAccounts(accounts, data); err != nil {
+			return nil, fmt.Errorf("[-] Failed to parse known accounts in %s: %w", path, err)
+		}
+	}
+
+	return accounts, nil
+}
+
+func addKnownAccounts(accounts map[string]KnownAccount, data []byte) error {
+	var entries []KnownAccount
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		accounts[entry.Id] = entry
+	}
+
+	return nil
+}
+
+// digitRunPattern matches whole runs of digits: the match always starts at the first digit of
+// a run and is greedy, so only runs of exactly 12 digits are account IDs. Matching the
+// delimiters around the ID instead would consume them and miss IDs like the second one in
+// 111111111111:222222222222.
+var digitRunPattern = regexp.MustCompile(`[0-9]{12,}`)
+
+// ExtractAccountIds returns every distinct account ID in a policy document, whether it appears
+// on its own, in an ARN or in a condition such as aws:SourceAccount.
+func ExtractAccountIds(document string) []string {
+	var accountIds []string
+	for _, match := range digitRunPattern.FindAllString(document, -1) {
+		if len(match) == 12 && !slices.Contains(accountIds, match) {
+			accountIds = append(accountIds, match)
+		}
+	}
+
+	return accountIds
+}
+
+// AccountSightings records which account IDs were seen where, in the order they were first seen.
+type AccountSightings struct {
+	Order   []string
+	Sources map[string][]string
+}
+
+func NewAccountSightings() *AccountSightings {
+	return &AccountSightings{Sources: make(map[string][]string)}
+}
+
+// Add records the account IDs of the policy document under the source, e.g. "trust policy of role admin".
+func (sightings *AccountSightings) Add(source string, document string) {
+	for _, accountId := range ExtractAccountIds(document) {
+		if _, seen := sightings.Sources[accountId]; !seen {
+			sightings.Order = append(sightings.Order, accountId)
+		}
+		if !slices.Contains(sightings.Sources[accountId], source) {
+			sightings.Sources[accountId] = append(sightings.Sources[accountId], source)
+		}
+	}
+}
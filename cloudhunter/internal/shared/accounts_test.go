@@ -0,0 +1,32 @@
+package shared
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractAccountIds(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     []string
+	}{
+		{"principal ARN", `{"Principal":{"AWS":"arn:aws:iam::111111111111:root"}}`, []string{"111111111111"}},
+		{"bare ID", `{"Principal":{"AWS":"111111111111"}}`, []string{"111111111111"}},
+		{"adjacent IDs", `111111111111:222222222222`, []string{"111111111111", "222222222222"}},
+		{"IDs separated by a single character", `111111111111,222222222222 333333333333`, []string{"111111111111", "222222222222", "333333333333"}},
+		{"duplicates", `arn:aws:iam::111111111111:role/a arn:aws:iam::111111111111:role/b`, []string{"111111111111"}},
+		{"condition", `{"Condition":{"StringEquals":{"aws:SourceAccount":["111111111111","222222222222"]}}}`, []string{"111111111111", "222222222222"}},
+		{"start and end of the document", `111111111111`, []string{"111111111111"}},
+		{"longer numbers", `1111111111111 11111111111 111111111111222222222222`, nil},
+		{"no IDs", `{"Principal":"*"}`, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ExtractAccountIds(test.document); !slices.Equal(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package shared
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ExtractZip extracts the archive into destination. Entries that would escape the
+// destination directory (zip slip) are refused.
+func ExtractZip(archivePath string, destination string) error {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		target, err := ContainedPath(destination, file.Name)
+		if err != nil {
+			return fmt.Errorf("archive entry %w", err)
+		}
+
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, os.ModePerm); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := extractZipFile(file, target); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func extractZipFile(file *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+
+	source, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, file.Mode().Perm()|0o200)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, source)
+	return err
+}
@@ -0,0 +1,99 @@
+package shared
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestZip(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for name, content := range entries {
+		entry, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := entry.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractZip(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "code.zip")
+	destination := filepath.Join(dir, "out")
+	writeTestZip(t, archive, map[string]string{
+		"index.py":         "handler",
+		"lib/helpers.py":   "helpers",
+		"lib/../config.py": "config",
+	})
+
+	if err := ExtractZip(archive, destination); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"index.py", "lib/helpers.py", "config.py"} {
+		if _, err := os.Stat(filepath.Join(destination, name)); err != nil {
+			t.Errorf("%s wasn't extracted: %v", name, err)
+		}
+	}
+}
+
+func TestExtractZipSlip(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry string
+	}{
+		{"parent directory", "../evil.sh"},
+		{"nested parent directory", "lib/../../evil.sh"},
+		{"deep parent directory", "../../../../../../tmp/evil.sh"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			archive := filepath.Join(dir, "code.zip")
+			destination := filepath.Join(dir, "out")
+			writeTestZip(t, archive, map[string]string{test.entry: "payload"})
+
+			if err := ExtractZip(archive, destination); err == nil {
+				t.Fatalf("expected %s to be refused", test.entry)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "evil.sh")); !os.IsNotExist(err) {
+				t.Errorf("%s was written outside the destination", test.entry)
+			}
+		})
+	}
+}
+
+func TestExtractZipAbsolutePath(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "code.zip")
+	destination := filepath.Join(dir, "out")
+	outside := filepath.Join(dir, "evil.sh")
+	writeTestZip(t, archive, map[string]string{outside: "payload"})
+
+	// Absolute entries are extracted below the destination rather than at their absolute path.
+	if err := ExtractZip(archive, destination); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(outside); !os.IsNotExist(err) {
+		t.Errorf("%s was written outside the destination", outside)
+	}
+	if _, err := os.Stat(filepath.Join(destination, outside)); err != nil {
+		t.Errorf("%s wasn't extracted below the destination: %v", outside, err)
+	}
+}
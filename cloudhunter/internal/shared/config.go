@@ -9,6 +9,10 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 )
 
+// DefaultRegion is used for clients that need a region to resolve endpoints
+// but were not given one explicitly (e.g. anonymous S3 requests).
+const DefaultRegion = "us-east-1"
+
 var ValidRegions = []string{
 	"ap-northeast-1", "ap-northeast-2", "ap-northeast-3",
 	"ap-south-1", "ap-southeast-1", "ap-southeast-2",
@@ -32,10 +36,62 @@ func GetAWSConfig(ctx context.Context, region, profile string) (aws.Config, erro
 		opts = append(opts, config.WithRegion(region))
 	}
 
+	transportOpts, err := transportLoadOptions()
+	if err != nil {
+		return aws.Config{}, err
+	}
+	opts = append(opts, transportOpts...)
+
 	cfg, err := config.LoadDefaultConfig(ctx, opts...)
 	if err != nil {
 		return cfg, fmt.Errorf("[-] Failed to load AWS config: %w", err)
 	}
+	applyEndpointOptions(&cfg)
+	applyUserAgentOptions(&cfg)
+	applyOpsecOptions(&cfg)
+	applyPacingOptions(&cfg)
 
 	return cfg, nil
 }
+
+// GetAnonymousAWSConfig returns a config that sends unsigned requests, used for
+// probing publicly exposed resources without any credentials.
+func GetAnonymousAWSConfig(ctx context.Context, region string) (aws.Config, error) {
+	if region == "" {
+		region = DefaultRegion
+	}
+
+	if !slices.Contains(ValidRegions, region) {
+		return aws.Config{}, fmt.Errorf("[-] Invalid AWS region: %s", region)
+	}
+
+	opts, err := transportLoadOptions()
+	if err != nil {
+		return aws.Config{}, err
+	}
+	opts = append(opts,
+		config.WithRegion(region),
+		config.WithCredentialsProvider(aws.AnonymousCredentials{}),
+	)
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return cfg, fmt.Errorf("[-] Failed to load AWS config: %w", err)
+	}
+	applyEndpointOptions(&cfg)
+	applyUserAgentOptions(&cfg)
+	applyOpsecOptions(&cfg)
+	applyPacingOptions(&cfg)
+
+	return cfg, nil
+}
+
+// RegionsToScan returns every valid region when allRegions is set, otherwise only the given region.
+// An empty region is kept as is so the profile's default region applies.
+func RegionsToScan(region string, allRegions bool) []string {
+	if allRegions {
+		return ValidRegions
+	}
+
+	return []string{region}
+}
@@ -0,0 +1,207 @@
+package shared
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/klauspost/compress/zstd"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrBinaryContent is returned when an object looks binary and raw output was not requested.
+var ErrBinaryContent = errors.New("object content is binary, use --raw to print it anyway")
+
+// maxCompressionLayers is how many nested gzip/zstd layers are unwrapped, so an archive that
+// decompresses to itself can't keep WriteObjectContent busy forever.
+const maxCompressionLayers = 4
+
+type ContentKind string
+
+const (
+	ContentText   ContentKind = "text"
+	ContentJSON   ContentKind = "json"
+	ContentYAML   ContentKind = "yaml"
+	ContentGzip   ContentKind = "gzip"
+	ContentZstd   ContentKind = "zstd"
+	ContentBinary ContentKind = "binary"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// DetectContentKind classifies content from its first bytes, using the object key
+// only to tell YAML apart from other plain text.
+func DetectContentKind(sample []byte, key string) ContentKind {
+	switch {
+	case bytes.HasPrefix(sample, gzipMagic):
+		return ContentGzip
+	case bytes.HasPrefix(sample, zstdMagic):
+		return ContentZstd
+	}
+
+	if bytes.IndexByte(sample, 0) != -1 {
+		return ContentBinary
+	}
+
+	// The sample may end in the middle of a multi-byte character.
+	trimmed := sample
+	for i := 0; i < utf8.UTFMax && len(trimmed) > 0 && !utf8.Valid(trimmed); i++ {
+		trimmed = trimmed[:len(trimmed)-1]
+	}
+	if !utf8.Valid(trimmed) {
+		return ContentBinary
+	}
+
+	if contentType := http.DetectContentType(sample); !strings.HasPrefix(contentType, "text/") && contentType != "application/octet-stream" {
+		return ContentBinary
+	}
+
+	text := bytes.TrimSpace(sample)
+	switch {
+	case bytes.HasPrefix(text, []byte("{")) || bytes.HasPrefix(text, []byte("[")):
+		return ContentJSON
+	case strings.HasSuffix(key, ".yaml") || strings.HasSuffix(key, ".yml") || bytes.HasPrefix(text, []byte("---")):
+		return ContentYAML
+	}
+
+	return ContentText
+}
+
+// WriteObjectContent writes the object to w, transparently decompressing gzip/zstd and
+// pretty-printing JSON and YAML. Binary content is refused unless raw is set, in which
+// case the bytes are copied unmodified. At most maxBytes of decompressed content are
+// written, so decompression bombs can't exhaust memory; a warning is logged when the
+// content is cut off.
+func WriteObjectContent(w io.Writer, body io.Reader, key string, raw bool, maxBytes int64) error {
+	if raw {
+		_, err := io.Copy(w, body)
+		return err
+	}
+
+	reader := bufio.NewReader(body)
+	for layers := 0; ; {
+		sample, _ := reader.Peek(512)
+
+		kind := DetectContentKind(sample, key)
+		if kind == ContentGzip || kind == ContentZstd {
+			if layers == maxCompressionLayers {
+				log.Printf("[!] Object is compressed more than %d times, not decompressing it any further", maxCompressionLayers)
+				return ErrBinaryContent
+			}
+			layers++
+		}
+
+		switch kind {
+		case ContentGzip:
+			decompressed, err := gzip.NewReader(reader)
+			if err != nil {
+				return err
+			}
+			defer decompressed.Close()
+			reader = bufio.NewReader(decompressed)
+			key = strings.TrimSuffix(key, ".gz")
+			continue
+		case ContentZstd:
+			decompressed, err := zstd.NewReader(reader)
+			if err != nil {
+				return err
+			}
+			defer decompressed.Close()
+			reader = bufio.NewReader(decompressed)
+			key = strings.TrimSuffix(key, ".zst")
+			continue
+		case ContentBinary:
+			return ErrBinaryContent
+		case ContentJSON:
+			return writeFormatted(w, reader, maxBytes, prettyJSON)
+		case ContentYAML:
+			return writeFormatted(w, reader, maxBytes, prettyYAML)
+		default:
+			return copyTruncated(w, reader, maxBytes)
+		}
+	}
+}
+
+// writeFormatted reads the content, up to maxBytes, and writes it through format, falling
+// back to the unmodified content when it cannot be parsed (e.g. a truncated byte range).
+func writeFormatted(w io.Writer, reader io.Reader, maxBytes int64, format func([]byte) ([]byte, error)) error {
+	data, err := io.ReadAll(io.LimitReader(reader, maxBytes+1))
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+
+	if int64(len(data)) > maxBytes {
+		data = data[:maxBytes]
+		defer warnOutputLimit(maxBytes)
+	} else if formatted, formatErr := format(data); formatErr == nil {
+		data = formatted
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// copyTruncated copies up to maxBytes of content that may have been cut off by a byte range
+// request, in which case decompressors report an unexpected EOF that is not worth failing on.
+func copyTruncated(w io.Writer, reader *bufio.Reader, maxBytes int64) error {
+	_, err := io.CopyN(w, reader, maxBytes)
+	switch {
+	case err == nil:
+		if _, peekErr := reader.Peek(1); peekErr == nil {
+			warnOutputLimit(maxBytes)
+		}
+		return nil
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		return nil
+	}
+
+	return err
+}
+
+func warnOutputLimit(maxBytes int64) {
+	log.Printf("[!] Stopped after %s of content, raise --max-output to see more", FormatBytes(maxBytes))
+}
+
+func prettyJSON(data []byte) ([]byte, error) {
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+
+	return out.Bytes(), nil
+}
+
+// prettyYAML re-indents every document of a multi-document stream, the encoder separates
+// them with "---" again.
+func prettyYAML(data []byte) ([]byte, error) {
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var document yaml.Node
+		if err := decoder.Decode(&document); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if err := encoder.Encode(&document); err != nil {
+			return nil, err
+		}
+	}
+
+	return out.Bytes(), encoder.Close()
+}
@@ -0,0 +1,75 @@
+package shared
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var out bytes.Buffer
+	writer := gzip.NewWriter(&out)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return out.Bytes()
+}
+
+func TestWriteObjectContentMultiDocumentYaml(t *testing.T) {
+	var out bytes.Buffer
+	content := "---\nfirst:    1\n---\nsecond:    2\n"
+	if err := WriteObjectContent(&out, strings.NewReader(content), "stack.yaml", false, 1<<20); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "first: 1\n---\nsecond: 2\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestWriteObjectContentOutputLimit(t *testing.T) {
+	var out bytes.Buffer
+	content := gzipBytes(t, bytes.Repeat([]byte("a"), 1000))
+	if err := WriteObjectContent(&out, bytes.NewReader(content), "log.gz", false, 100); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 100 {
+		t.Errorf("wrote %d bytes, want 100", out.Len())
+	}
+
+	out.Reset()
+	if err := WriteObjectContent(&out, strings.NewReader(`{"key": "`+strings.Repeat("a", 100)+`"}`), "data.json", false, 50); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 50 {
+		t.Errorf("wrote %d bytes of JSON, want 50", out.Len())
+	}
+}
+
+func TestWriteObjectContentCompressionLayers(t *testing.T) {
+	content := []byte("plain text\n")
+	for range maxCompressionLayers {
+		content = gzipBytes(t, content)
+	}
+
+	var out bytes.Buffer
+	if err := WriteObjectContent(&out, bytes.NewReader(content), "nested.gz", false, 1<<20); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "plain text\n" {
+		t.Errorf("got %q", out.String())
+	}
+
+	err := WriteObjectContent(&out, bytes.NewReader(gzipBytes(t, content)), "nested.gz", false, 1<<20)
+	if !errors.Is(err, ErrBinaryContent) {
+		t.Errorf("got %v for %d layers, want ErrBinaryContent", err, maxCompressionLayers+1)
+	}
+}
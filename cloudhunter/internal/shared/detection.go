@@ -0,0 +1,392 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EventCategory is how CloudTrail classifies an API call, which decides whether a trail records it.
+type EventCategory int
+
+const (
+	ManagementRead EventCategory = iota
+	ManagementWrite
+	S3DataRead
+	S3DataWrite
+	// NotLogged is used for work done locally, such as signing presigned URLs.
+	NotLogged
+)
+
+func (category EventCategory) String() string {
+	switch category {
+	case ManagementRead:
+		return "management read"
+	case ManagementWrite:
+		return "management write"
+	case S3DataRead:
+		return "S3 data read"
+	case S3DataWrite:
+		return "S3 data write"
+	default:
+		return "not logged"
+	}
+}
+
+// ApiCall is a single AWS API call made by a command.
+type ApiCall struct {
+	Name     string
+	Category EventCategory
+}
+
+// WrapperActivity lists the API calls a wrapper method of internal/aws sends itself and the
+// other wrapper methods it runs, whose calls are listed under their own entry.
+type WrapperActivity struct {
+	Calls []ApiCall
+	Uses  []string
+}
+
+// CommandActivity lists the wrapper methods a CloudHunter command runs, keyed by its command
+// path without the program name, e.g. "s3 cat".
+type CommandActivity struct {
+	Command  string
+	Wrappers []string
+}
+
+func managementReads(names ...string) []ApiCall {
+	return calls(ManagementRead, names...)
+}
+
+func calls(category EventCategory, names ...string) []ApiCall {
+	var result []ApiCall
+	for _, name := range names {
+		result = append(result, ApiCall{Name: name, Category: category})
+	}
+	return result
+}
+
+// WrapperActivities is the registry of what every wrapper method sends to AWS, keyed by
+// "<wrapper type>.<method>". Update it together with the wrapper: a test in internal/aws
+// checks that every exported wrapper method has an entry.
+var WrapperActivities = map[string]WrapperActivity{
+	"AutoScalingWrapper.DescribeLaunchConfigurationsWrapper": {Calls: managementReads("autoscaling:DescribeLaunchConfigurations")},
+
+	"DetectionWrapper.DescribeTrailsWrapper":                      {Calls: managementReads("cloudtrail:DescribeTrails")},
+	"DetectionWrapper.GetTrailStatusWrapper":                      {Calls: managementReads("cloudtrail:GetTrailStatus")},
+	"DetectionWrapper.GetEventSelectorsWrapper":                   {Calls: managementReads("cloudtrail:GetEventSelectors")},
+	"DetectionWrapper.ListDetectorsWrapper":                       {Calls: managementReads("guardduty:ListDetectors")},
+	"DetectionWrapper.GetDetectorWrapper":                         {Calls: managementReads("guardduty:GetDetector")},
+	"DetectionWrapper.DescribeHubWrapper":                         {Calls: managementReads("securityhub:DescribeHub")},
+	"DetectionWrapper.GetEnabledStandardsWrapper":                 {Calls: managementReads("securityhub:GetEnabledStandards")},
+	"DetectionWrapper.DescribeConfigurationRecordersWrapper":      {Calls: managementReads("config:DescribeConfigurationRecorders")},
+	"DetectionWrapper.DescribeConfigurationRecorderStatusWrapper": {Calls: managementReads("config:DescribeConfigurationRecorderStatus")},
+	"DetectionWrapper.ListAnalyzersWrapper":                       {Calls: managementReads("access-analyzer:ListAnalyzers")},
+	"DetectionWrapper.DescribeAlarmsWrapper":                      {Calls: managementReads("cloudwatch:DescribeAlarms")},
+
+	"Ec2Wrapper.DescribeInstancesWrapper":              {Calls: managementReads("ec2:DescribeInstances")},
+	"Ec2Wrapper.DescribeSecurityGroupsWrapper":         {Calls: managementReads("ec2:DescribeSecurityGroups")},
+	"Ec2Wrapper.DescribeKeyPairsWrapper":               {Calls: managementReads("ec2:DescribeKeyPairs")},
+	"Ec2Wrapper.DescribeVpcsWrapper":                   {Calls: managementReads("ec2:DescribeVpcs")},
+	"Ec2Wrapper.DescribeSubnetsWrapper":                {Calls: managementReads("ec2:DescribeSubnets")},
+	"Ec2Wrapper.DescribeNetworkInterfacesWrapper":      {Calls: managementReads("ec2:DescribeNetworkInterfaces")},
+	"Ec2Wrapper.GetInstanceUserDataWrapper":            {Calls: managementReads("ec2:DescribeInstanceAttribute")},
+	"Ec2Wrapper.DescribeLaunchTemplatesWrapper":        {Calls: managementReads("ec2:DescribeLaunchTemplates")},
+	"Ec2Wrapper.DescribeLaunchTemplateVersionsWrapper": {Calls: managementReads("ec2:DescribeLaunchTemplateVersions")},
+	"Ec2Wrapper.DescribeSnapshotsWrapper":              {Calls: managementReads("ec2:DescribeSnapshots")},
+	"Ec2Wrapper.GetSnapshotSharingWrapper":             {Calls: managementReads("ec2:DescribeSnapshotAttribute")},
+	"Ec2Wrapper.DescribeImagesWrapper":                 {Calls: managementReads("ec2:DescribeImages")},
+	"Ec2Wrapper.GetImageSharingWrapper":                {Calls: managementReads("ec2:DescribeImageAttribute")},
+
+	"IamWrapper.ListUsersWrapper":              {Calls: managementReads("iam:ListUsers")},
+	"IamWrapper.GetUserWrapper":                {Calls: managementReads("iam:GetUser")},
+	"IamWrapper.ListAccessKeysWrapper":         {Calls: managementReads("iam:ListAccessKeys")},
+	"IamWrapper.ListUserPoliciesWrapper":       {Calls: managementReads("iam:ListUserPolicies")},
+	"IamWrapper.GetUserPolicyWrapper":          {Calls: managementReads("iam:GetUserPolicy")},
+	"IamWrapper.ListGroupsWrapper":             {Calls: managementReads("iam:ListGroups")},
+	"IamWrapper.ListGroupsForUserWrapper":      {Calls: managementReads("iam:ListGroupsForUser")},
+	"IamWrapper.GetGroupWrapper":               {Calls: managementReads("iam:GetGroup")},
+	"IamWrapper.ListGroupPoliciesWrapper":      {Calls: managementReads("iam:ListGroupPolicies")},
+	"IamWrapper.GetGroupPolicyDocumentWrapper": {Calls: managementReads("iam:GetGroupPolicy")},
+	"IamWrapper.ListRolesWrapper":              {Calls: managementReads("iam:ListRoles")},
+	"IamWrapper.ListAllRolesWrapper":           {Calls: managementReads("iam:ListRoles")},
+	"IamWrapper.GetRoleWrapper":                {Calls: managementReads("iam:GetRole")},
+	"IamWrapper.ListRolePoliciesWrapper":       {Calls: managementReads("iam:ListRolePolicies")},
+	"IamWrapper.GetRolePolicyDocumentWrapper":  {Calls: managementReads("iam:GetRolePolicy")},
+	"IamWrapper.CallerPrincipalArnWrapper":     {Uses: []string{"IamWrapper.GetRoleWrapper"}},
+	"IamWrapper.CollectPrincipalPoliciesWrapper": {
+		Uses: []string{"IamWrapper.ListUserPoliciesWrapper", "IamWrapper.GetUserPolicyWrapper", "IamWrapper.ListAttachedUserPoliciesWrapper",
+			"IamWrapper.ListGroupsForUserWrapper", "IamWrapper.ListGroupPoliciesWrapper", "IamWrapper.GetGroupPolicyDocumentWrapper", "IamWrapper.ListAttachedGroupPoliciesWrapper",
+			"IamWrapper.ListRolePoliciesWrapper", "IamWrapper.GetRolePolicyDocumentWrapper", "IamWrapper.ListAttachedRolePoliciesWrapper", "IamWrapper.GetManagedPolicyDocumentWrapper"},
+	},
+	"IamWrapper.ListAttachedUserPoliciesWrapper":  {Calls: managementReads("iam:ListAttachedUserPolicies")},
+	"IamWrapper.ListAttachedGroupPoliciesWrapper": {Calls: managementReads("iam:ListAttachedGroupPolicies")},
+	"IamWrapper.ListAttachedRolePoliciesWrapper":  {Calls: managementReads("iam:ListAttachedRolePolicies")},
+	"IamWrapper.GetManagedPolicyDocumentWrapper":  {Calls: managementReads("iam:GetPolicy", "iam:GetPolicyVersion")},
+	"IamWrapper.ListIdentityPoliciesWrapper":      {Calls: managementReads("iam:GetAccountAuthorizationDetails")},
+	"IamWrapper.SimulatePrincipalPolicyWrapper":   {Calls: managementReads("iam:SimulatePrincipalPolicy")},
+	"IamWrapper.GetRoleTrustPolicyWrapper":        {Calls: managementReads("iam:GetRole")},
+	"IamWrapper.UpdateAssumeRolePolicyWrapper":    {Calls: calls(ManagementWrite, "iam:UpdateAssumeRolePolicy")},
+	"IamWrapper.PrincipalExistsWrapper":           {Uses: []string{"IamWrapper.UpdateAssumeRolePolicyWrapper"}},
+
+	"KmsWrapper.ListKeysWrapper":     {Calls: managementReads("kms:ListKeys")},
+	"KmsWrapper.ListAliasesWrapper":  {Calls: managementReads("kms:ListAliases")},
+	"KmsWrapper.DescribeKeyWrapper":  {Calls: managementReads("kms:DescribeKey")},
+	"KmsWrapper.GetKeyPolicyWrapper": {Calls: managementReads("kms:GetKeyPolicy")},
+	"KmsWrapper.ListGrantsWrapper":   {Calls: managementReads("kms:ListGrants")},
+
+	"LambdaWrapper.ListFunctionsWrapper":           {Calls: managementReads("lambda:ListFunctions")},
+	"LambdaWrapper.ListFunctionUrlConfigsWrapper":  {Calls: managementReads("lambda:ListFunctionUrlConfigs")},
+	"LambdaWrapper.ListEventSourceMappingsWrapper": {Calls: managementReads("lambda:ListEventSourceMappings")},
+	"LambdaWrapper.GetPolicyWrapper":               {Calls: managementReads("lambda:GetPolicy")},
+	"LambdaWrapper.DownloadFunctionCodeWrapper":    {Calls: managementReads("lambda:GetFunction")},
+
+	"OrganizationsWrapper.DescribeOrganizationWrapper":                {Calls: managementReads("organizations:DescribeOrganization")},
+	"OrganizationsWrapper.ListAccountsWrapper":                        {Calls: managementReads("organizations:ListAccounts")},
+	"OrganizationsWrapper.ListRootsWrapper":                           {Calls: managementReads("organizations:ListRoots")},
+	"OrganizationsWrapper.ListOrganizationalUnitsWrapper":             {Calls: managementReads("organizations:ListOrganizationalUnitsForParent")},
+	"OrganizationsWrapper.ListAccountsForParentWrapper":               {Calls: managementReads("organizations:ListAccountsForParent")},
+	"OrganizationsWrapper.ListParentsWrapper":                         {Calls: managementReads("organizations:ListParents")},
+	"OrganizationsWrapper.ListServiceControlPoliciesWrapper":          {Calls: managementReads("organizations:ListPolicies")},
+	"OrganizationsWrapper.ListServiceControlPoliciesForTargetWrapper": {Calls: managementReads("organizations:ListPoliciesForTarget")},
+	"OrganizationsWrapper.GetPolicyContentWrapper":                    {Calls: managementReads("organizations:DescribePolicy")},
+	"OrganizationsWrapper.ListTargetsForPolicyWrapper":                {Calls: managementReads("organizations:ListTargetsForPolicy")},
+	"OrganizationsWrapper.ListDelegatedAdministratorsWrapper":         {Calls: managementReads("organizations:ListDelegatedAdministrators")},
+	"OrganizationsWrapper.ListDelegatedServicesWrapper":               {Calls: managementReads("organizations:ListDelegatedServicesForAccount")},
+	"OrganizationsWrapper.AccountServiceControlPoliciesWrapper": {
+		Uses: []string{"OrganizationsWrapper.DescribeOrganizationWrapper", "OrganizationsWrapper.CollectServiceControlPoliciesWrapper"},
+	},
+	"OrganizationsWrapper.CollectServiceControlPoliciesWrapper": {
+		Uses: []string{"OrganizationsWrapper.ListServiceControlPoliciesForTargetWrapper", "OrganizationsWrapper.GetPolicyContentWrapper", "OrganizationsWrapper.ListParentsWrapper"},
+	},
+
+	"RdsWrapper.DescribeDBSnapshotsWrapper":         {Calls: managementReads("rds:DescribeDBSnapshots")},
+	"RdsWrapper.GetDBSnapshotSharingWrapper":        {Calls: managementReads("rds:DescribeDBSnapshotAttributes")},
+	"RdsWrapper.DescribeDBClusterSnapshotsWrapper":  {Calls: managementReads("rds:DescribeDBClusterSnapshots")},
+	"RdsWrapper.GetDBClusterSnapshotSharingWrapper": {Calls: managementReads("rds:DescribeDBClusterSnapshotAttributes")},
+
+	"S3Wrapper.ListBuckets":            {Calls: managementReads("s3:ListBuckets")},
+	"S3Wrapper.ResolveBucketRegion":    {Calls: append(calls(S3DataRead, "s3:HeadBucket"), managementReads("s3:GetBucketLocation")...)},
+	"S3Wrapper.BucketClient":           {Uses: []string{"S3Wrapper.ResolveBucketRegion"}},
+	"S3Wrapper.RequesterPaysBuckets":   {},
+	"S3Wrapper.CredentialsExpiry":      {},
+	"S3Wrapper.ListObjects":            {Uses: []string{"S3Wrapper.WalkObjects"}},
+	"S3Wrapper.WalkObjects":            {Calls: calls(S3DataRead, "s3:ListObjectsV2"), Uses: []string{"S3Wrapper.BucketClient"}},
+	"S3Wrapper.ListS3BucketContent":    {Calls: calls(S3DataRead, "s3:ListObjectsV2"), Uses: []string{"S3Wrapper.BucketClient"}},
+	"S3Wrapper.TakeSnapshot":           {Uses: []string{"S3Wrapper.ListObjects"}},
+	"S3Wrapper.GetObjectMetadata":      {Calls: calls(S3DataRead, "s3:HeadObject", "s3:GetObjectTagging"), Uses: []string{"S3Wrapper.BucketClient"}},
+	"S3Wrapper.PopulateObjectMetadata": {Uses: []string{"S3Wrapper.GetObjectMetadata"}},
+	"S3Wrapper.GetObjectStream":        {Calls: calls(S3DataRead, "s3:GetObject"), Uses: []string{"S3Wrapper.BucketClient"}},
+	"S3Wrapper.ReadObject":             {Uses: []string{"S3Wrapper.GetObjectStream"}},
+	"S3Wrapper.DownloadObject":         {Calls: calls(S3DataRead, "s3:GetObject"), Uses: []string{"S3Wrapper.BucketClient"}},
+	"S3Wrapper.DumpBucketWrapper":      {Calls: calls(S3DataRead, "s3:ListObjectsV2"), Uses: []string{"S3Wrapper.BucketClient", "S3Wrapper.DownloadObject"}},
+	"S3Wrapper.ProbeBucket":            {Calls: calls(S3DataRead, "s3:HeadBucket", "s3:ListObjectsV2", "s3:GetObject")},
+	"S3Wrapper.DiscoverBuckets":        {Uses: []string{"S3Wrapper.ProbeBucket"}},
+	"S3Wrapper.GetBucketPolicy":        {Calls: managementReads("s3:GetBucketPolicy"), Uses: []string{"S3Wrapper.BucketClient"}},
+	"S3Wrapper.ProbeBucketPermissions": {
+		Calls: append(calls(S3DataWrite, "s3:PutObject", "s3:PutObjectAcl", "s3:DeleteObject", "s3:DeleteObjectVersion"), calls(ManagementWrite, "s3:PutBucketPolicy")...),
+		Uses:  []string{"S3Wrapper.BucketClient", "S3Wrapper.GetBucketPolicy"},
+	},
+	"S3Wrapper.PresignGetObject": {Calls: calls(NotLogged, "presigning"), Uses: []string{"S3Wrapper.BucketClient"}},
+
+	"InitializeS3ControlWrapper":                           {Uses: []string{"StsWrapper.GetCallerIdentityWrapper"}},
+	"S3ControlWrapper.ListAccessPointsWrapper":             {Calls: managementReads("s3:ListAccessPoints", "s3:GetAccessPointPolicy")},
+	"S3ControlWrapper.ListObjectLambdaAccessPointsWrapper": {Calls: managementReads("s3:ListAccessPointsForObjectLambda", "s3:GetAccessPointPolicyForObjectLambda")},
+	"S3ControlWrapper.ListMultiRegionAccessPointsWrapper":  {Calls: managementReads("s3:ListMultiRegionAccessPoints", "s3:GetMultiRegionAccessPointPolicy")},
+
+	"SecretsManagerWrapper.ListSecretsWrapper":        {Calls: managementReads("secretsmanager:ListSecrets")},
+	"SecretsManagerWrapper.GetResourcePolicyWrapper":  {Calls: managementReads("secretsmanager:GetResourcePolicy")},
+	"SecretsManagerWrapper.ListSecretVersionsWrapper": {Calls: managementReads("secretsmanager:ListSecretVersionIds")},
+	"SecretsManagerWrapper.GetSecretValueWrapper":     {Calls: managementReads("secretsmanager:GetSecretValue")},
+
+	"SsmWrapper.DescribeParametersWrapper":          {Calls: managementReads("ssm:DescribeParameters")},
+	"SsmWrapper.GetParametersByPathWrapper":         {Calls: managementReads("ssm:GetParametersByPath", "kms:Decrypt")},
+	"SsmWrapper.GetParametersWrapper":               {Calls: managementReads("ssm:GetParameters", "kms:Decrypt")},
+	"SsmWrapper.DescribeInstanceInformationWrapper": {Calls: managementReads("ssm:DescribeInstanceInformation")},
+	"SsmWrapper.ListOwnedDocumentsWrapper":          {Calls: managementReads("ssm:ListDocuments")},
+	"SsmWrapper.GetDocumentSharingWrapper":          {Calls: managementReads("ssm:DescribeDocumentPermission")},
+
+	"StsWrapper.GetCallerIdentityWrapper": {Calls: managementReads("sts:GetCallerIdentity")},
+}
+
+// CommandActivities is the registry of the wrapper methods every command runs. Keep it in
+// sync when adding commands or changing which wrappers a command uses.
+var CommandActivities = []CommandActivity{
+	{Command: "iam users", Wrappers: []string{"IamWrapper.ListUsersWrapper"}},
+	{Command: "iam get-user", Wrappers: []string{"IamWrapper.GetUserWrapper"}},
+	{Command: "iam access-keys", Wrappers: []string{"IamWrapper.ListAccessKeysWrapper"}},
+	{Command: "iam user-policies", Wrappers: []string{"IamWrapper.ListUserPoliciesWrapper"}},
+	{Command: "iam get-user-policy-document", Wrappers: []string{"IamWrapper.GetUserPolicyWrapper"}},
+	{Command: "iam groups", Wrappers: []string{"IamWrapper.ListGroupsWrapper"}},
+	{Command: "iam user-groups", Wrappers: []string{"IamWrapper.ListGroupsForUserWrapper"}},
+	{Command: "iam get-group", Wrappers: []string{"IamWrapper.GetGroupWrapper"}},
+	{Command: "iam group-policies", Wrappers: []string{"IamWrapper.ListGroupPoliciesWrapper"}},
+	{Command: "iam get-group-policy-document", Wrappers: []string{"IamWrapper.GetGroupPolicyDocumentWrapper"}},
+	{Command: "iam roles", Wrappers: []string{"IamWrapper.ListRolesWrapper"}},
+	{Command: "iam get-role", Wrappers: []string{"IamWrapper.GetRoleWrapper"}},
+	{Command: "iam role-policies", Wrappers: []string{"IamWrapper.ListRolePoliciesWrapper"}},
+	{Command: "iam get-role-policy-document", Wrappers: []string{"IamWrapper.GetRolePolicyDocumentWrapper"}},
+
+	{Command: "s3 buckets", Wrappers: []string{"S3Wrapper.ListBuckets", "S3Wrapper.ResolveBucketRegion"}},
+	{Command: "s3 list-content", Wrappers: []string{"S3Wrapper.RequesterPaysBuckets", "S3Wrapper.ListS3BucketContent", "S3Wrapper.PopulateObjectMetadata"}},
+	{Command: "s3 dump-bucket", Wrappers: []string{"S3Wrapper.RequesterPaysBuckets", "S3Wrapper.DumpBucketWrapper"}},
+	{Command: "s3 discover", Wrappers: []string{"S3Wrapper.DiscoverBuckets"}},
+	{Command: "s3 probe-permissions", Wrappers: []string{"StsWrapper.GetCallerIdentityWrapper", "OrganizationsWrapper.AccountServiceControlPoliciesWrapper",
+		"S3Wrapper.ListBuckets", "S3Wrapper.RequesterPaysBuckets", "S3Wrapper.ProbeBucketPermissions"}},
+	{Command: "s3 presign", Wrappers: []string{"S3Wrapper.RequesterPaysBuckets", "S3Wrapper.CredentialsExpiry", "S3Wrapper.ListObjects", "S3Wrapper.PresignGetObject"}},
+	{Command: "s3 cat", Wrappers: []string{"S3Wrapper.GetObjectStream"}},
+	{Command: "s3 head-bytes", Wrappers: []string{"S3Wrapper.GetObjectStream"}},
+	{Command: "s3 snapshot", Wrappers: []string{"S3Wrapper.RequesterPaysBuckets", "S3Wrapper.TakeSnapshot"}},
+	{Command: "s3 diff", Wrappers: []string{"S3Wrapper.RequesterPaysBuckets", "S3Wrapper.TakeSnapshot", "S3Wrapper.DownloadObject"}},
+	{Command: "s3 access-points", Wrappers: []string{"InitializeS3ControlWrapper", "S3ControlWrapper.ListAccessPointsWrapper", "S3ControlWrapper.ListObjectLambdaAccessPointsWrapper", "S3ControlWrapper.ListMultiRegionAccessPointsWrapper"}},
+	{Command: "s3 tfstate", Wrappers: []string{"S3Wrapper.RequesterPaysBuckets", "S3Wrapper.ListBuckets", "S3Wrapper.WalkObjects", "S3Wrapper.ReadObject"}},
+
+	{Command: "ec2 instances", Wrappers: []string{"Ec2Wrapper.DescribeInstancesWrapper"}},
+	{Command: "ec2 security-groups", Wrappers: []string{"Ec2Wrapper.DescribeSecurityGroupsWrapper"}},
+	{Command: "ec2 key-pairs", Wrappers: []string{"Ec2Wrapper.DescribeKeyPairsWrapper"}},
+	{Command: "ec2 vpcs", Wrappers: []string{"Ec2Wrapper.DescribeVpcsWrapper", "Ec2Wrapper.DescribeSubnetsWrapper"}},
+	{Command: "ec2 enis", Wrappers: []string{"Ec2Wrapper.DescribeNetworkInterfacesWrapper"}},
+	{Command: "ec2 user-data", Wrappers: []string{"Ec2Wrapper.DescribeInstancesWrapper", "Ec2Wrapper.GetInstanceUserDataWrapper", "Ec2Wrapper.DescribeLaunchTemplatesWrapper", "Ec2Wrapper.DescribeLaunchTemplateVersionsWrapper", "AutoScalingWrapper.DescribeLaunchConfigurationsWrapper"}},
+	{Command: "ec2 snapshots", Wrappers: []string{"Ec2Wrapper.DescribeSnapshotsWrapper", "Ec2Wrapper.GetSnapshotSharingWrapper"}},
+	{Command: "ec2 amis", Wrappers: []string{"Ec2Wrapper.DescribeImagesWrapper", "Ec2Wrapper.GetImageSharingWrapper"}},
+
+	{Command: "rds snapshots", Wrappers: []string{"RdsWrapper.DescribeDBSnapshotsWrapper", "RdsWrapper.GetDBSnapshotSharingWrapper", "RdsWrapper.DescribeDBClusterSnapshotsWrapper", "RdsWrapper.GetDBClusterSnapshotSharingWrapper"}},
+
+	{Command: "lambda functions", Wrappers: []string{"LambdaWrapper.ListFunctionsWrapper", "LambdaWrapper.ListFunctionUrlConfigsWrapper", "LambdaWrapper.ListEventSourceMappingsWrapper", "LambdaWrapper.GetPolicyWrapper"}},
+	{Command: "lambda download-code", Wrappers: []string{"LambdaWrapper.DownloadFunctionCodeWrapper"}},
+
+	{Command: "secrets list", Wrappers: []string{"SecretsManagerWrapper.ListSecretsWrapper", "SecretsManagerWrapper.GetResourcePolicyWrapper", "SecretsManagerWrapper.ListSecretVersionsWrapper", "SecretsManagerWrapper.GetSecretValueWrapper"}},
+
+	{Command: "ssm parameters", Wrappers: []string{"SsmWrapper.DescribeParametersWrapper"}},
+	{Command: "ssm get-parameters", Wrappers: []string{"SsmWrapper.GetParametersByPathWrapper", "SsmWrapper.DescribeParametersWrapper", "SsmWrapper.GetParametersWrapper"}},
+	{Command: "ssm instances", Wrappers: []string{"StsWrapper.GetCallerIdentityWrapper", "IamWrapper.CallerPrincipalArnWrapper", "OrganizationsWrapper.AccountServiceControlPoliciesWrapper",
+		"SsmWrapper.DescribeInstanceInformationWrapper", "IamWrapper.SimulatePrincipalPolicyWrapper"}},
+	{Command: "ssm documents", Wrappers: []string{"SsmWrapper.ListOwnedDocumentsWrapper", "SsmWrapper.GetDocumentSharingWrapper"}},
+
+	{Command: "kms keys", Wrappers: []string{"StsWrapper.GetCallerIdentityWrapper", "IamWrapper.CallerPrincipalArnWrapper", "IamWrapper.CollectPrincipalPoliciesWrapper",
+		"KmsWrapper.ListKeysWrapper", "KmsWrapper.ListAliasesWrapper", "KmsWrapper.DescribeKeyWrapper", "KmsWrapper.GetKeyPolicyWrapper", "KmsWrapper.ListGrantsWrapper",
+		"OrganizationsWrapper.AccountServiceControlPoliciesWrapper"}},
+
+	{Command: "org accounts", Wrappers: []string{"OrganizationsWrapper.DescribeOrganizationWrapper", "OrganizationsWrapper.ListAccountsWrapper"}},
+	{Command: "org ous", Wrappers: []string{"OrganizationsWrapper.ListRootsWrapper", "OrganizationsWrapper.ListOrganizationalUnitsWrapper"}},
+	{Command: "org tree", Wrappers: []string{"OrganizationsWrapper.DescribeOrganizationWrapper", "OrganizationsWrapper.ListRootsWrapper", "OrganizationsWrapper.ListOrganizationalUnitsWrapper",
+		"OrganizationsWrapper.ListAccountsForParentWrapper", "OrganizationsWrapper.ListServiceControlPoliciesForTargetWrapper"}},
+	{Command: "org scps", Wrappers: []string{"OrganizationsWrapper.ListServiceControlPoliciesWrapper", "OrganizationsWrapper.ListTargetsForPolicyWrapper", "OrganizationsWrapper.GetPolicyContentWrapper"}},
+	{Command: "org delegated-admins", Wrappers: []string{"OrganizationsWrapper.ListDelegatedAdministratorsWrapper", "OrganizationsWrapper.ListDelegatedServicesWrapper"}},
+	{Command: "org evaluate-scps", Wrappers: []string{"StsWrapper.GetCallerIdentityWrapper", "OrganizationsWrapper.DescribeOrganizationWrapper", "OrganizationsWrapper.CollectServiceControlPoliciesWrapper"}},
+
+	{Command: "recon logging", Wrappers: []string{"DetectionWrapper.DescribeTrailsWrapper", "DetectionWrapper.GetTrailStatusWrapper", "DetectionWrapper.GetEventSelectorsWrapper",
+		"DetectionWrapper.ListDetectorsWrapper", "DetectionWrapper.GetDetectorWrapper", "DetectionWrapper.DescribeHubWrapper", "DetectionWrapper.GetEnabledStandardsWrapper",
+		"DetectionWrapper.DescribeConfigurationRecordersWrapper", "DetectionWrapper.DescribeConfigurationRecorderStatusWrapper", "DetectionWrapper.ListAnalyzersWrapper", "DetectionWrapper.DescribeAlarmsWrapper"}},
+	{Command: "recon accounts", Wrappers: []string{"StsWrapper.GetCallerIdentityWrapper", "IamWrapper.ListAllRolesWrapper", "IamWrapper.ListIdentityPoliciesWrapper",
+		"S3Wrapper.ListBuckets", "S3Wrapper.GetBucketPolicy", "InitializeS3ControlWrapper", "S3ControlWrapper.ListAccessPointsWrapper",
+		"S3ControlWrapper.ListObjectLambdaAccessPointsWrapper", "S3ControlWrapper.ListMultiRegionAccessPointsWrapper",
+		"KmsWrapper.ListKeysWrapper", "KmsWrapper.GetKeyPolicyWrapper", "IamWrapper.GetRoleTrustPolicyWrapper", "IamWrapper.PrincipalExistsWrapper", "IamWrapper.UpdateAssumeRolePolicyWrapper"}},
+}
+
+// FindCommandActivity returns the registry entry of the command, if there is one.
+func FindCommandActivity(command string) (CommandActivity, bool) {
+	for _, activity := range CommandActivities {
+		if activity.Command == command {
+			return activity, true
+		}
+	}
+
+	return CommandActivity{}, false
+}
+
+// Calls returns every API call the command's wrapper methods make, including those of the
+// wrapper methods they run in turn, in the order they are first listed.
+func (activity CommandActivity) Calls() []ApiCall {
+	var result []ApiCall
+	seenCalls := make(map[string]bool)
+	seenWrappers := make(map[string]bool)
+
+	var collect func(wrapper string)
+	collect = func(wrapper string) {
+		if seenWrappers[wrapper] {
+			return
+		}
+		seenWrappers[wrapper] = true
+
+		entry := WrapperActivities[wrapper]
+		for _, call := range entry.Calls {
+			if !seenCalls[call.Name] {
+				seenCalls[call.Name] = true
+				result = append(result, call)
+			}
+		}
+		for _, used := range entry.Uses {
+			collect(used)
+		}
+	}
+
+	for _, wrapper := range activity.Wrappers {
+		collect(wrapper)
+	}
+
+	return result
+}
+
+// LoggingCoverage records which event categories are delivered by at least one active trail.
+type LoggingCoverage struct {
+	ManagementRead  bool
+	ManagementWrite bool
+	S3DataRead      bool
+	S3DataWrite     bool
+	LambdaData      bool
+}
+
+// Merge combines the coverage of several trails.
+func (coverage LoggingCoverage) Merge(other LoggingCoverage) LoggingCoverage {
+	return LoggingCoverage{
+		ManagementRead:  coverage.ManagementRead || other.ManagementRead,
+		ManagementWrite: coverage.ManagementWrite || other.ManagementWrite,
+		S3DataRead:      coverage.S3DataRead || other.S3DataRead,
+		S3DataWrite:     coverage.S3DataWrite || other.S3DataWrite,
+		LambdaData:      coverage.LambdaData || other.LambdaData,
+	}
+}
+
+// Covers reports whether events of the category end up in a trail.
+func (coverage LoggingCoverage) Covers(category EventCategory) bool {
+	switch category {
+	case ManagementRead:
+		return coverage.ManagementRead
+	case ManagementWrite:
+		return coverage.ManagementWrite
+	case S3DataRead:
+		return coverage.S3DataRead
+	case S3DataWrite:
+		return coverage.S3DataWrite
+	default:
+		return false
+	}
+}
+
+// Visibility summarizes how the command's calls would show up to defenders. Management
+// events always reach the 90 day CloudTrail event history, even without a trail.
+func (coverage LoggingCoverage) Visibility(activity CommandActivity) string {
+	var logged, historyOnly, unlogged []string
+	for _, call := range activity.Calls() {
+		switch {
+		case coverage.Covers(call.Category):
+			logged = append(logged, call.Name)
+		case call.Category == ManagementRead || call.Category == ManagementWrite:
+			historyOnly = append(historyOnly, call.Name)
+		default:
+			unlogged = append(unlogged, call.Name)
+		}
+	}
+
+	var parts []string
+	if len(logged) > 0 {
+		parts = append(parts, fmt.Sprintf("VISIBLE in trails (%s)", strings.Join(logged, ", ")))
+	}
+	if len(historyOnly) > 0 {
+		parts = append(parts, fmt.Sprintf("event history only (%s)", strings.Join(historyOnly, ", ")))
+	}
+	if len(unlogged) > 0 {
+		parts = append(parts, fmt.Sprintf("not logged (%s)", strings.Join(unlogged, ", ")))
+	}
+
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,55 @@
+package shared
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCommandActivitiesAreRegistered(t *testing.T) {
+	for _, activity := range CommandActivities {
+		for _, wrapper := range activity.Wrappers {
+			if _, ok := WrapperActivities[wrapper]; !ok {
+				t.Errorf("%s runs %s, which isn't in WrapperActivities", activity.Command, wrapper)
+			}
+		}
+	}
+
+	for name, activity := range WrapperActivities {
+		for _, used := range activity.Uses {
+			if _, ok := WrapperActivities[used]; !ok {
+				t.Errorf("%s uses %s, which isn't in WrapperActivities", name, used)
+			}
+		}
+	}
+}
+
+func TestCommandActivityCalls(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"s3 probe-permissions", []string{"sts:GetCallerIdentity", "organizations:DescribeOrganization", "organizations:ListPoliciesForTarget", "organizations:DescribePolicy", "organizations:ListParents",
+			"s3:ListBuckets", "s3:PutObject", "s3:PutObjectAcl", "s3:DeleteObject", "s3:DeleteObjectVersion", "s3:PutBucketPolicy", "s3:HeadBucket", "s3:GetBucketLocation", "s3:GetBucketPolicy"}},
+		{"s3 cat", []string{"s3:GetObject", "s3:HeadBucket", "s3:GetBucketLocation"}},
+		{"s3 access-points", []string{"sts:GetCallerIdentity", "s3:ListAccessPoints", "s3:GetAccessPointPolicy", "s3:ListAccessPointsForObjectLambda", "s3:GetAccessPointPolicyForObjectLambda", "s3:ListMultiRegionAccessPoints", "s3:GetMultiRegionAccessPointPolicy"}},
+		{"recon accounts", []string{"sts:GetCallerIdentity", "iam:ListRoles", "iam:GetAccountAuthorizationDetails", "s3:ListBuckets", "s3:GetBucketPolicy", "s3:HeadBucket", "s3:GetBucketLocation",
+			"s3:ListAccessPoints", "s3:GetAccessPointPolicy", "s3:ListAccessPointsForObjectLambda", "s3:GetAccessPointPolicyForObjectLambda", "s3:ListMultiRegionAccessPoints",
+			"s3:GetMultiRegionAccessPointPolicy", "kms:ListKeys", "kms:GetKeyPolicy", "iam:GetRole", "iam:UpdateAssumeRolePolicy"}},
+	}
+
+	for _, test := range tests {
+		activity, ok := FindCommandActivity(test.command)
+		if !ok {
+			t.Errorf("%s isn't registered", test.command)
+			continue
+		}
+
+		var names []string
+		for _, call := range activity.Calls() {
+			names = append(names, call.Name)
+		}
+		if !slices.Equal(names, test.want) {
+			t.Errorf("%s: got %v, want %v", test.command, names, test.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Endpoint settings, set from the global --endpoint-url and --path-style flags.
+var (
+	// EndpointUrl replaces the AWS endpoints of every service.
+	EndpointUrl string
+	// ServiceEndpointUrls replaces the endpoints of single services, keyed by IAM service
+	// prefix ("s3", "iam", ...) or "s3control". They take precedence over EndpointUrl.
+	ServiceEndpointUrls = map[string]string{}
+	// S3PathStyle addresses buckets as endpoint/bucket instead of bucket.endpoint, which
+	// MinIO, Ceph and LocalStack usually need.
+	S3PathStyle bool
+)
+
+// endpointServiceKeys overrides the IAM service prefix for services that share one with another
+// service but have their own endpoint: S3 Control calls are s3 actions, but s3=URL mustn't
+// redirect them.
+var endpointServiceKeys = map[string]string{
+	"S3 Control": "s3control",
+}
+
+// endpointServiceKey returns the key a service's endpoint is looked up by in ServiceEndpointUrls.
+func endpointServiceKey(serviceId string) string {
+	if key, ok := endpointServiceKeys[serviceId]; ok {
+		return key
+	}
+	if prefix, ok := serviceActionPrefixes[serviceId]; ok {
+		return prefix
+	}
+
+	return strings.ToLower(strings.ReplaceAll(serviceId, " ", ""))
+}
+
+// ParseEndpointUrls parses the values of --endpoint-url, which are either a URL used for
+// every service or service=URL for a single one, e.g. s3=http://localhost:9000. Services
+// CloudHunter doesn't talk to are rejected, so that a typo doesn't go unnoticed.
+func ParseEndpointUrls(values []string) error {
+	for _, value := range values {
+		service, endpoint, found := strings.Cut(value, "=")
+		if !found || strings.Contains(service, "://") {
+			service, endpoint = "", value
+		}
+
+		parsed, err := url.Parse(endpoint)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("[-] Invalid endpoint URL %q, expected e.g. http://localhost:4566 or s3=http://localhost:9000", value)
+		}
+
+		service = strings.ToLower(service)
+		switch {
+		case service == "":
+			EndpointUrl = endpoint
+		case !slices.Contains(endpointServices(), service):
+			return fmt.Errorf("[-] Unknown service %q in endpoint URL %q, expected one of %s", service, value, strings.Join(endpointServices(), ", "))
+		default:
+			ServiceEndpointUrls[service] = endpoint
+		}
+	}
+
+	return nil
+}
+
+// endpointServices returns the sorted service names --endpoint-url accepts.
+func endpointServices() []string {
+	var services []string
+	for serviceId := range maps.Keys(serviceActionPrefixes) {
+		services = append(services, endpointServiceKey(serviceId))
+	}
+	slices.Sort(services)
+
+	return slices.Compact(services)
+}
+
+// serviceEndpointSource hands the per service endpoints to the SDK, which asks every config
+// source for a service specific endpoint when building a client.
+type serviceEndpointSource struct{}
+
+func (serviceEndpointSource) GetServiceBaseEndpoint(ctx context.Context, serviceId string) (string, bool, error) {
+	endpoint, ok := ServiceEndpointUrls[endpointServiceKey(serviceId)]
+	return endpoint, ok, nil
+}
+
+// applyEndpointOptions points clients built from the config at the custom endpoints.
+func applyEndpointOptions(cfg *aws.Config) {
+	if EndpointUrl != "" {
+		cfg.BaseEndpoint = aws.String(EndpointUrl)
+	}
+	if len(ServiceEndpointUrls) > 0 {
+		cfg.ConfigSources = append([]interface{}{serviceEndpointSource{}}, cfg.ConfigSources...)
+	}
+}
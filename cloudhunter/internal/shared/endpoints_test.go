@@ -0,0 +1,96 @@
+package shared
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseEndpointUrls(t *testing.T) {
+	tests := []struct {
+		name        string
+		values      []string
+		wantDefault string
+		wantService map[string]string
+		wantErr     bool
+	}{
+		{
+			name:        "no endpoints",
+			wantService: map[string]string{},
+		},
+		{
+			name:        "single URL",
+			values:      []string{"http://localhost:4566"},
+			wantDefault: "http://localhost:4566",
+			wantService: map[string]string{},
+		},
+		{
+			name:        "service list",
+			values:      []string{"s3=http://localhost:9000", "IAM=http://localhost:4566", "s3control=http://localhost:9001"},
+			wantService: map[string]string{"s3": "http://localhost:9000", "iam": "http://localhost:4566", "s3control": "http://localhost:9001"},
+		},
+		{
+			name:        "URL with a query string",
+			values:      []string{"http://localhost:4566/?a=b"},
+			wantDefault: "http://localhost:4566/?a=b",
+			wantService: map[string]string{},
+		},
+		{
+			name:    "unknown service",
+			values:  []string{"s4=http://localhost:9000"},
+			wantErr: true,
+		},
+		{
+			name:    "malformed URL",
+			values:  []string{"s3=localhost:9000"},
+			wantErr: true,
+		},
+		{
+			name:    "missing URL",
+			values:  []string{"s3="},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			EndpointUrl, ServiceEndpointUrls = "", map[string]string{}
+			t.Cleanup(func() { EndpointUrl, ServiceEndpointUrls = "", map[string]string{} })
+
+			err := ParseEndpointUrls(test.values)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %t", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if EndpointUrl != test.wantDefault {
+				t.Errorf("got endpoint %q, want %q", EndpointUrl, test.wantDefault)
+			}
+			if !maps.Equal(ServiceEndpointUrls, test.wantService) {
+				t.Errorf("got service endpoints %v, want %v", ServiceEndpointUrls, test.wantService)
+			}
+		})
+	}
+}
+
+func TestServiceEndpointSource(t *testing.T) {
+	ServiceEndpointUrls = map[string]string{"s3": "http://localhost:9000"}
+	t.Cleanup(func() { ServiceEndpointUrls = map[string]string{} })
+
+	tests := []struct {
+		serviceId string
+		want      string
+		wantFound bool
+	}{
+		{"S3", "http://localhost:9000", true},
+		{"S3 Control", "", false},
+		{"IAM", "", false},
+	}
+
+	for _, test := range tests {
+		endpoint, found, err := serviceEndpointSource{}.GetServiceBaseEndpoint(t.Context(), test.serviceId)
+		if err != nil || endpoint != test.want || found != test.wantFound {
+			t.Errorf("GetServiceBaseEndpoint(%q) = %q, %t, %v, want %q, %t", test.serviceId, endpoint, found, err, test.want, test.wantFound)
+		}
+	}
+}
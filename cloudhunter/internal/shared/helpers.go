@@ -1,32 +1,157 @@
 package shared
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 )
 
 func ParseJsonPolicyDocument(policyData string) string {
 	decodedPolicy, err := url.QueryUnescape(policyData)
 	if err != nil {
-		log.Fatal(err)
+		Fatal(err)
 	}
 
 	var policyObj any
 	err = json.Unmarshal([]byte(decodedPolicy), &policyObj)
 	if err != nil {
-		log.Fatal(err)
+		Fatal(err)
 	}
 
 	policy, err := json.MarshalIndent(policyObj, "", "  ")
 	if err != nil {
-		log.Fatal(err)
+		Fatal(err)
 	}
 
 	return string(policy)
 }
 
+// IndentJsonDocument pretty prints a raw JSON document, such as the resource policies most
+// services return. Unlike ParseJsonPolicyDocument it doesn't URL-decode the document, which
+// would mangle "+" and "%" in condition values, and leaves handling errors to the caller.
+func IndentJsonDocument(document string) (string, error) {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, []byte(document), "", "  "); err != nil {
+		return "", err
+	}
+
+	return indented.String(), nil
+}
+
+// IndentedOrRaw returns the indented JSON document, or the document as it is when it can't be
+// parsed, logging why. What names the document in the log line, e.g. "key policy of <arn>".
+func IndentedOrRaw(document string, what string) string {
+	indented, err := IndentJsonDocument(document)
+	if err != nil {
+		log.Printf("[!] Couldn't parse %s: %v", what, err)
+		return document
+	}
+
+	return indented
+}
+
+// RenderBucketContentLong renders the tree with size, last modified date and storage class
+// of every object, and the total size of every folder. Metadata is printed when it was collected.
+func RenderBucketContentLong(nodes []*S3Node, indent string) {
+	for _, node := range nodes {
+		if node.IsFolder {
+			fmt.Printf("%s %s [%s total]\n", indent, node.Name, FormatBytes(node.TotalSize()))
+			RenderBucketContentLong(node.Children, indent+"  ")
+			continue
+		}
+
+		fmt.Printf("%s %s [%s, %s, %s", indent, node.Name, FormatBytes(node.Size), node.LastModified.Format(time.DateTime), node.StorageClass)
+		if node.Owner != "" {
+			fmt.Printf(", owner: %s", node.Owner)
+		}
+		fmt.Printf(", etag: %s]\n", node.ETag)
+
+		if node.Metadata != nil {
+			renderObjectMetadata(node.Metadata, indent+"    ")
+		}
+	}
+}
+
+func renderObjectMetadata(metadata *S3ObjectMetadata, indent string) {
+	if metadata.ServerSideEncryption != "" {
+		fmt.Printf("%s SSE: %s\n", indent, metadata.ServerSideEncryption)
+	}
+	if metadata.KMSKeyId != "" {
+		fmt.Printf("%s KMS key: %s\n", indent, metadata.KMSKeyId)
+	}
+	if metadata.ObjectLockMode != "" {
+		fmt.Printf("%s Object lock: %s", indent, metadata.ObjectLockMode)
+		if metadata.ObjectLockRetainDate != nil {
+			fmt.Printf(" until %s", metadata.ObjectLockRetainDate.Format(time.DateTime))
+		}
+		fmt.Println()
+	}
+	if metadata.ObjectLockLegalHold != "" {
+		fmt.Printf("%s Legal hold: %s\n", indent, metadata.ObjectLockLegalHold)
+	}
+	for _, key := range sortedKeys(metadata.UserMetadata) {
+		fmt.Printf("%s Metadata: %s=%s\n", indent, key, metadata.UserMetadata[key])
+	}
+	for _, key := range sortedKeys(metadata.Tags) {
+		fmt.Printf("%s Tag: %s=%s\n", indent, key, metadata.Tags[key])
+	}
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// FormatBytes renders a byte count in a human-readable form, e.g. 1.5 MiB.
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// RenderBucketNode prints a single node by its full key, used when streaming a listing.
+func RenderBucketNode(node *S3Node, long bool) {
+	if node.IsFolder || !long {
+		fmt.Printf("  %s\n", node.Key)
+		return
+	}
+
+	fmt.Printf("  %s [%s, %s, %s]\n", node.Key, FormatBytes(node.Size), node.LastModified.Format(time.DateTime), node.StorageClass)
+}
+
+// RenderFolderTotals prints the total size of every folder by its full key, for listings
+// that were streamed.
+func RenderFolderTotals(nodes []*S3Node) {
+	for _, node := range nodes {
+		if node.IsFolder {
+			fmt.Printf("  %s [%s total]\n", node.Key, FormatBytes(node.TotalSize()))
+			RenderFolderTotals(node.Children)
+		}
+	}
+}
+
 func RenderBucketContent(nodes []*S3Node, indent string) {
 	for _, node := range nodes {
 		if node.IsFolder {
@@ -37,3 +162,55 @@ func RenderBucketContent(nodes []*S3Node, indent string) {
 		}
 	}
 }
+
+// ReadLines returns the non-empty lines of the file at path.
+func ReadLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+// ContainedPath joins name to root and refuses names, such as "../../.bashrc", that would
+// end up outside of root. It is used for local paths derived from untrusted object keys
+// and archive entries.
+func ContainedPath(root string, name string) (string, error) {
+	absoluteRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+
+	target := filepath.Join(absoluteRoot, name)
+	if target != absoluteRoot && !strings.HasPrefix(target, absoluteRoot+string(os.PathSeparator)) {
+		return "", fmt.Errorf("%s escapes the directory %s", name, root)
+	}
+
+	return target, nil
+}
+
+// Fatal is log.Fatal for commands: it prints the run summary before exiting, which a
+// deferred call or PersistentPostRun wouldn't get to do.
+func Fatal(v ...any) {
+	log.Output(2, fmt.Sprint(v...))
+	PrintRunSummary()
+	os.Exit(1)
+}
+
+// Fatalf is log.Fatalf, printing the run summary before exiting like Fatal.
+func Fatalf(format string, v ...any) {
+	log.Output(2, fmt.Sprintf(format, v...))
+	PrintRunSummary()
+	os.Exit(1)
+}
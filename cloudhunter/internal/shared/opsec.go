@@ -0,0 +1,220 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// NoiseLevel rates how likely an API call is to be noticed by defenders.
+type NoiseLevel int
+
+const (
+	// NoiseUnlimited disables --quiet-level enforcement.
+	NoiseUnlimited NoiseLevel = iota
+	NoiseLow
+	NoiseMedium
+	NoiseHigh
+)
+
+func (level NoiseLevel) String() string {
+	switch level {
+	case NoiseLow:
+		return "low"
+	case NoiseMedium:
+		return "medium"
+	case NoiseHigh:
+		return "high"
+	default:
+		return "unlimited"
+	}
+}
+
+// ParseNoiseLevel parses the value of --quiet-level. An empty value means no limit.
+func ParseNoiseLevel(value string) (NoiseLevel, error) {
+	switch strings.ToLower(value) {
+	case "":
+		return NoiseUnlimited, nil
+	case "low":
+		return NoiseLow, nil
+	case "medium":
+		return NoiseMedium, nil
+	case "high":
+		return NoiseHigh, nil
+	}
+
+	return NoiseUnlimited, fmt.Errorf("[-] Invalid quiet level %q, expected low, medium or high", value)
+}
+
+// ApiRisk is the detection risk of an API call: its noise rating and the GuardDuty finding
+// types it is known to contribute to.
+type ApiRisk struct {
+	Noise    NoiseLevel
+	Findings []string
+}
+
+const (
+	findingIamDiscovery      = "Discovery:IAMUser/AnomalousBehavior"
+	findingCredentialAccess  = "CredentialAccess:IAMUser/AnomalousBehavior"
+	findingPersistence       = "Persistence:IAMUser/AnomalousBehavior"
+	findingS3Discovery       = "Discovery:S3/AnomalousBehavior"
+	findingS3Exfiltration    = "Exfiltration:S3/AnomalousBehavior"
+	findingS3Write           = "Impact:S3/AnomalousBehavior.Write"
+	findingS3Delete          = "Impact:S3/AnomalousBehavior.Delete"
+	findingS3Permission      = "Impact:S3/AnomalousBehavior.Permission"
+	findingS3AnonymousAccess = "Policy:S3/BucketAnonymousAccessGranted"
+)
+
+// apiRisks rates the calls CloudHunter makes that don't fit the defaults of defaultApiRisk.
+var apiRisks = map[string]ApiRisk{
+	"sts:GetCallerIdentity": {Noise: NoiseLow},
+	"presigning":            {Noise: NoiseLow},
+
+	"iam:GetAccountAuthorizationDetails": {Noise: NoiseHigh, Findings: []string{findingIamDiscovery}},
+	"iam:ListAccessKeys":                 {Noise: NoiseHigh, Findings: []string{findingIamDiscovery}},
+	"iam:SimulatePrincipalPolicy":        {Noise: NoiseMedium, Findings: []string{findingIamDiscovery}},
+	"iam:UpdateAssumeRolePolicy":         {Noise: NoiseHigh, Findings: []string{findingPersistence}},
+
+	"s3:HeadBucket":          {Noise: NoiseLow},
+	"s3:HeadObject":          {Noise: NoiseLow},
+	"s3:GetBucketLocation":   {Noise: NoiseLow},
+	"s3:ListBuckets":         {Noise: NoiseMedium, Findings: []string{findingS3Discovery}},
+	"s3:ListObjectsV2":       {Noise: NoiseMedium, Findings: []string{findingS3Discovery}},
+	"s3:GetObject":           {Noise: NoiseMedium, Findings: []string{findingS3Exfiltration}},
+	"s3:GetBucketPolicy":     {Noise: NoiseMedium, Findings: []string{findingS3Discovery}},
+	"s3:PutObject":           {Noise: NoiseHigh, Findings: []string{findingS3Write}},
+	"s3:PutObjectAcl":        {Noise: NoiseHigh, Findings: []string{findingS3Permission, findingS3AnonymousAccess}},
+	"s3:DeleteObject":        {Noise: NoiseHigh, Findings: []string{findingS3Delete}},
+	"s3:DeleteObjectVersion": {Noise: NoiseHigh, Findings: []string{findingS3Delete}},
+	"s3:PutBucketPolicy":     {Noise: NoiseHigh, Findings: []string{findingS3Permission, findingS3AnonymousAccess}},
+
+	"ec2:DescribeInstanceAttribute": {Noise: NoiseHigh, Findings: []string{findingCredentialAccess}},
+
+	"lambda:GetFunction": {Noise: NoiseHigh, Findings: []string{findingCredentialAccess}},
+
+	"secretsmanager:GetSecretValue": {Noise: NoiseHigh, Findings: []string{findingCredentialAccess}},
+	"ssm:GetParametersByPath":       {Noise: NoiseHigh, Findings: []string{findingCredentialAccess}},
+	"ssm:GetParameters":             {Noise: NoiseHigh, Findings: []string{findingCredentialAccess}},
+	"kms:Decrypt":                   {Noise: NoiseHigh, Findings: []string{findingCredentialAccess}},
+
+	"kms:ListAliases": {Noise: NoiseLow},
+	"kms:DescribeKey": {Noise: NoiseLow},
+}
+
+// ApiCallRisk returns the risk of an API call such as "s3:ListBuckets".
+func ApiCallRisk(call string) ApiRisk {
+	if risk, ok := apiRisks[call]; ok {
+		return risk
+	}
+
+	return defaultApiRisk(call)
+}
+
+// defaultApiRisk rates calls by their verb: reads feed GuardDuty's anomalous discovery
+// models, anything else changes the environment and is rated high.
+func defaultApiRisk(call string) ApiRisk {
+	_, operation, _ := strings.Cut(call, ":")
+	for _, prefix := range []string{"Describe", "List", "Get"} {
+		if strings.HasPrefix(operation, prefix) {
+			return ApiRisk{Noise: NoiseMedium, Findings: []string{findingIamDiscovery}}
+		}
+	}
+	if strings.HasPrefix(operation, "Head") {
+		return ApiRisk{Noise: NoiseLow}
+	}
+
+	return ApiRisk{Noise: NoiseHigh, Findings: []string{findingPersistence}}
+}
+
+// QuietLevel is the highest noise rating calls may have, set from the global --quiet-level flag.
+var QuietLevel = NoiseUnlimited
+
+// serviceActionPrefixes maps SDK service IDs to the service prefix used in IAM actions.
+var serviceActionPrefixes = map[string]string{
+	"AccessAnalyzer":  "access-analyzer",
+	"Auto Scaling":    "autoscaling",
+	"CloudTrail":      "cloudtrail",
+	"CloudWatch":      "cloudwatch",
+	"Config Service":  "config",
+	"EC2":             "ec2",
+	"GuardDuty":       "guardduty",
+	"IAM":             "iam",
+	"KMS":             "kms",
+	"Lambda":          "lambda",
+	"Organizations":   "organizations",
+	"RDS":             "rds",
+	"S3":              "s3",
+	"S3 Control":      "s3",
+	"SSM":             "ssm",
+	"STS":             "sts",
+	"Secrets Manager": "secretsmanager",
+	"SecurityHub":     "securityhub",
+}
+
+// ApiCallName returns the IAM style name ("s3:ListBuckets") of the operation a middleware stack runs.
+func ApiCallName(ctx context.Context) string {
+	serviceId := awsmiddleware.GetServiceID(ctx)
+	prefix, ok := serviceActionPrefixes[serviceId]
+	if !ok {
+		prefix = strings.ToLower(strings.ReplaceAll(serviceId, " ", ""))
+	}
+
+	return prefix + ":" + awsmiddleware.GetOperationName(ctx)
+}
+
+// quietLevelMiddleware refuses calls rated above QuietLevel before they are sent.
+var quietLevelMiddleware = middleware.FinalizeMiddlewareFunc("QuietLevel",
+	func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+		call := ApiCallName(ctx)
+		if risk := ApiCallRisk(call); QuietLevel != NoiseUnlimited && risk.Noise > QuietLevel {
+			return middleware.FinalizeOutput{}, middleware.Metadata{}, fmt.Errorf("[-] Refusing %s: rated %s noise, above --quiet-level %s", call, risk.Noise, QuietLevel)
+		}
+
+		return next.HandleFinalize(ctx, in)
+	})
+
+// isPresignStack reports whether the stack presigns a request instead of sending it. The
+// presign clients swap the signer for this middleware before the API options run.
+func isPresignStack(stack *middleware.Stack) bool {
+	_, ok := stack.Finalize.Get("PresignHTTPRequest")
+	return ok
+}
+
+// applyOpsecOptions adds the OPSEC middleware to every client built from the config.
+// Presigning is done locally, so presign stacks are left alone.
+func applyOpsecOptions(cfg *aws.Config) {
+	cfg.APIOptions = append(cfg.APIOptions, func(stack *middleware.Stack) error {
+		if isPresignStack(stack) {
+			return nil
+		}
+		return stack.Finalize.Add(quietLevelMiddleware, middleware.Before)
+	})
+}
+
+// PrintPlan prints the API calls the command would make, their risk and whether
+// --quiet-level would let them through.
+func PrintPlan(command string) {
+	activity, ok := FindCommandActivity(command)
+	if !ok {
+		fmt.Printf("[-] No plan is known for %s\n", command)
+		return
+	}
+
+	fmt.Printf("[!] Plan for %s:\n", command)
+	for _, call := range activity.Calls() {
+		risk := ApiCallRisk(call.Name)
+		status := ""
+		if QuietLevel != NoiseUnlimited && risk.Noise > QuietLevel {
+			status = " [BLOCKED BY --quiet-level]"
+		}
+
+		fmt.Printf(" %s (%s, %s noise)%s\n", call.Name, call.Category, risk.Noise, status)
+		for _, finding := range risk.Findings {
+			fmt.Printf("  GuardDuty: %s\n", finding)
+		}
+	}
+}
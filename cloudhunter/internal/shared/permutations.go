@@ -0,0 +1,105 @@
+package shared
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var bucketEnvironments = []string{
+	"dev", "development", "staging", "stage", "stg", "prod", "production",
+	"test", "qa", "uat", "sandbox", "int",
+}
+
+var bucketSuffixes = []string{
+	"backup", "backups", "bak", "logs", "log", "data", "assets", "static",
+	"media", "files", "uploads", "public", "private", "internal", "archive",
+	"terraform", "tfstate", "config", "cdn", "www", "web", "images",
+	"documents", "db", "database",
+}
+
+var bucketSeparators = []string{"-", ".", ""}
+
+var validBucketName = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// GenerateBucketNames builds candidate bucket names from the given company keywords
+// by combining them with common environment names, suffixes, separators and recent years.
+func GenerateBucketNames(keywords []string) []string {
+	currentYear := time.Now().Year()
+	years := []string{
+		strconv.Itoa(currentYear),
+		strconv.Itoa(currentYear - 1),
+		strconv.Itoa(currentYear - 2),
+	}
+
+	words := append(append([]string{}, bucketEnvironments...), bucketSuffixes...)
+
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if seen[name] || !IsValidBucketName(name) {
+			return
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	for _, keyword := range keywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword == "" {
+			continue
+		}
+
+		add(keyword)
+
+		for _, sep := range bucketSeparators {
+			for _, word := range words {
+				add(keyword + sep + word)
+				add(word + sep + keyword)
+			}
+
+			for _, env := range bucketEnvironments {
+				for _, suffix := range bucketSuffixes {
+					add(keyword + sep + env + sep + suffix)
+				}
+			}
+
+			for _, year := range years {
+				add(keyword + sep + year)
+				for _, suffix := range bucketSuffixes {
+					add(keyword + sep + suffix + sep + year)
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+var ipAddressName = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+\.[0-9]+$`)
+
+// reservedBucketPrefixes and reservedBucketSuffixes are reserved by S3 for access point
+// aliases, Object Lambda and Multi-Region Access Points.
+var reservedBucketPrefixes = []string{"xn--", "sthree-", "amzn-s3-demo-"}
+var reservedBucketSuffixes = []string{"-s3alias", "--ol-s3", ".mrap", "--x-s3", "--table-s3"}
+
+// IsValidBucketName reports whether name follows the S3 bucket naming rules.
+func IsValidBucketName(name string) bool {
+	if !validBucketName.MatchString(name) || ipAddressName.MatchString(name) {
+		return false
+	}
+
+	for _, prefix := range reservedBucketPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	for _, suffix := range reservedBucketSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return false
+		}
+	}
+
+	return !strings.Contains(name, "..") && !strings.Contains(name, ".-") && !strings.Contains(name, "-.")
+}
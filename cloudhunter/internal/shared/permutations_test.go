@@ -0,0 +1,69 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsValidBucketName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"acme", true},
+		{"acme-prod-backups", true},
+		{"acme.prod.logs", true},
+		{"abc", true},
+		{"ab", false},
+		{strings.Repeat("a", 63), true},
+		{strings.Repeat("a", 64), false},
+		{"Acme", false},
+		{"acme_prod", false},
+		{"-acme", false},
+		{"acme-", false},
+		{".acme", false},
+		{"192.168.1.10", false},
+		{"192.168.1.10x", true},
+		{"xn--acme", false},
+		{"sthree-acme", false},
+		{"acme-s3alias", false},
+		{"acme--ol-s3", false},
+		{"acme.mrap", false},
+		{"acme..prod", false},
+		{"acme.-prod", false},
+		{"acme-.prod", false},
+	}
+
+	for _, test := range tests {
+		if got := IsValidBucketName(test.name); got != test.valid {
+			t.Errorf("IsValidBucketName(%q) = %t, want %t", test.name, got, test.valid)
+		}
+	}
+}
+
+func TestGenerateBucketNames(t *testing.T) {
+	names := GenerateBucketNames([]string{" Acme ", "", "acme"})
+
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if !IsValidBucketName(name) {
+			t.Errorf("generated invalid bucket name %q", name)
+		}
+		if seen[name] {
+			t.Errorf("generated %q more than once", name)
+		}
+		seen[name] = true
+	}
+
+	for _, expected := range []string{"acme", "acme-prod", "prod-acme", "acme.backup", "acme-prod-logs"} {
+		if !seen[expected] {
+			t.Errorf("expected %q among the generated names", expected)
+		}
+	}
+}
+
+func TestGenerateBucketNamesSkipsInvalidKeywords(t *testing.T) {
+	if names := GenerateBucketNames([]string{"a_b"}); len(names) != 0 {
+		t.Errorf("expected no names for an invalid keyword, got %d", len(names))
+	}
+}
@@ -0,0 +1,413 @@
+package shared
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// PolicyDecision is the outcome of evaluating policies for a single action and resource.
+type PolicyDecision int
+
+const (
+	PolicyImplicitDeny PolicyDecision = iota
+	PolicyAllow
+	PolicyExplicitDeny
+	// PolicyConditional means that the outcome depends on conditions, which aren't evaluated:
+	// either only statements with conditions allow the action, or a statement with conditions
+	// denies what others allow.
+	PolicyConditional
+)
+
+func (decision PolicyDecision) String() string {
+	switch decision {
+	case PolicyAllow:
+		return "allowed"
+	case PolicyExplicitDeny:
+		return "explicitly denied"
+	case PolicyConditional:
+		return "conditional"
+	default:
+		return "implicitly denied"
+	}
+}
+
+// StringList is a policy element that may be written as a single string or a list of strings.
+type StringList []string
+
+func (list *StringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*list = StringList{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*list = multiple
+
+	return nil
+}
+
+// PolicyPrincipal maps principal types (AWS, Service, Federated, ...) to their values.
+// The anonymous "*" principal is stored as {"AWS": ["*"]}.
+type PolicyPrincipal map[string]StringList
+
+func (principal *PolicyPrincipal) UnmarshalJSON(data []byte) error {
+	var wildcard string
+	if err := json.Unmarshal(data, &wildcard); err == nil {
+		*principal = PolicyPrincipal{"AWS": {wildcard}}
+		return nil
+	}
+
+	var principals map[string]StringList
+	if err := json.Unmarshal(data, &principals); err != nil {
+		return err
+	}
+	*principal = principals
+
+	return nil
+}
+
+type PolicyStatement struct {
+	Sid         string
+	Effect      string
+	Principal   PolicyPrincipal
+	Action      StringList
+	NotAction   StringList
+	Resource    StringList
+	NotResource StringList
+	Condition   map[string]any
+}
+
+// Statements is the Statement element, which may be a single statement or a list.
+type Statements []PolicyStatement
+
+func (statements *Statements) UnmarshalJSON(data []byte) error {
+	var single PolicyStatement
+	if err := json.Unmarshal(data, &single); err == nil {
+		*statements = Statements{single}
+		return nil
+	}
+
+	var multiple []PolicyStatement
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*statements = multiple
+
+	return nil
+}
+
+type PolicyDocument struct {
+	Version   string
+	Statement Statements
+}
+
+// NamedPolicy is a policy document together with where it was found, e.g. "inline user policy admin".
+type NamedPolicy struct {
+	Name     string
+	Document PolicyDocument
+}
+
+// ParsePolicyDocument parses a policy document, URL-decoding it first when it comes
+// URL-encoded, as IAM returns them.
+func ParsePolicyDocument(policyData string) (PolicyDocument, error) {
+	if !strings.HasPrefix(strings.TrimSpace(policyData), "{") {
+		decoded, err := url.QueryUnescape(policyData)
+		if err != nil {
+			return PolicyDocument{}, err
+		}
+		policyData = decoded
+	}
+
+	var document PolicyDocument
+	err := json.Unmarshal([]byte(policyData), &document)
+	return document, err
+}
+
+// Evaluate evaluates the identity policy for the action on the resource. Conditions are not
+// evaluated: when the decision depends on them it is PolicyConditional, and the condition
+// keys it depends on are returned.
+func (document PolicyDocument) Evaluate(action string, resource string) (PolicyDecision, []string) {
+	return document.evaluate(action, resource, func(PolicyStatement) bool { return true }).result()
+}
+
+// EvaluateForPrincipal evaluates a resource policy for the action on the resource, taking
+// only the statements that name the principal, its account or everyone into account.
+func (document PolicyDocument) EvaluateForPrincipal(action string, resource string, principalArn string) (PolicyDecision, []string) {
+	return document.evaluate(action, resource, func(statement PolicyStatement) bool {
+		return statement.namesPrincipal(principalArn, false)
+	}).result()
+}
+
+// DelegatesToAccount reports whether the resource policy allows the action for the whole
+// account of the principal, leaving the decision to the principal's IAM policies. The
+// returned condition keys are set when the delegation depends on conditions.
+func (document PolicyDocument) DelegatesToAccount(action string, resource string, principalArn string) (bool, []string) {
+	decision, conditions := document.evaluate(action, resource, func(statement PolicyStatement) bool {
+		return statement.namesPrincipal(principalArn, true)
+	}).result()
+
+	return decision == PolicyAllow || decision == PolicyConditional, conditions
+}
+
+// DenyStatementsFor returns a document holding only the Deny statements of the resource policy
+// that name the principal, its account or everyone. Evaluated together with the principal's
+// identity policies they make a resource policy's denies count on the path it delegates to IAM.
+func (document PolicyDocument) DenyStatementsFor(principalArn string) PolicyDocument {
+	denies := PolicyDocument{Version: document.Version}
+	for _, statement := range document.Statement {
+		if strings.EqualFold(statement.Effect, "Deny") && (statement.namesPrincipal(principalArn, false) || statement.namesPrincipal(principalArn, true)) {
+			denies.Statement = append(denies.Statement, statement)
+		}
+	}
+
+	return denies
+}
+
+func (document PolicyDocument) evaluate(action string, resource string, applies func(PolicyStatement) bool) policyTally {
+	var tally policyTally
+	for _, statement := range document.Statement {
+		if applies(statement) && statement.matches(action, resource) {
+			tally.add(statement)
+		}
+	}
+
+	return tally
+}
+
+// policyTally accumulates the statements matching a request, separating those with
+// conditions, so that the statements of several policies can be combined.
+type policyTally struct {
+	allow               bool
+	explicitDeny        bool
+	conditionalAllows   []string
+	conditionalDenies   []string
+	hasConditionalAllow bool
+	hasConditionalDeny  bool
+}
+
+func (tally *policyTally) add(statement PolicyStatement) {
+	conditional := len(statement.Condition) > 0
+
+	switch {
+	case strings.EqualFold(statement.Effect, "Deny") && conditional:
+		tally.hasConditionalDeny = true
+		tally.conditionalDenies = appendConditionKeys(tally.conditionalDenies, statement.Condition)
+	case strings.EqualFold(statement.Effect, "Deny"):
+		tally.explicitDeny = true
+	case strings.EqualFold(statement.Effect, "Allow") && conditional:
+		tally.hasConditionalAllow = true
+		tally.conditionalAllows = appendConditionKeys(tally.conditionalAllows, statement.Condition)
+	case strings.EqualFold(statement.Effect, "Allow"):
+		tally.allow = true
+	}
+}
+
+func (tally *policyTally) merge(other policyTally) {
+	tally.allow = tally.allow || other.allow
+	tally.explicitDeny = tally.explicitDeny || other.explicitDeny
+	tally.hasConditionalAllow = tally.hasConditionalAllow || other.hasConditionalAllow
+	tally.hasConditionalDeny = tally.hasConditionalDeny || other.hasConditionalDeny
+	for _, key := range other.conditionalAllows {
+		tally.conditionalAllows = appendUnique(tally.conditionalAllows, key)
+	}
+	for _, key := range other.conditionalDenies {
+		tally.conditionalDenies = appendUnique(tally.conditionalDenies, key)
+	}
+}
+
+// result returns the decision and, when it is conditional, the condition keys it depends on.
+// A deny with conditions only matters when something allows the action, and an allow with
+// conditions only when nothing allows it unconditionally.
+func (tally policyTally) result() (PolicyDecision, []string) {
+	switch {
+	case tally.explicitDeny:
+		return PolicyExplicitDeny, nil
+	case tally.allow && tally.hasConditionalDeny:
+		return PolicyConditional, tally.conditionalDenies
+	case tally.allow:
+		return PolicyAllow, nil
+	case tally.hasConditionalAllow && tally.hasConditionalDeny:
+		conditions := slices.Clone(tally.conditionalAllows)
+		for _, key := range tally.conditionalDenies {
+			conditions = appendUnique(conditions, key)
+		}
+		return PolicyConditional, conditions
+	case tally.hasConditionalAllow:
+		return PolicyConditional, tally.conditionalAllows
+	default:
+		return PolicyImplicitDeny, nil
+	}
+}
+
+// appendConditionKeys appends the condition keys, such as aws:SourceIp, of a Condition element,
+// which maps operators to keys and their values.
+func appendConditionKeys(keys []string, condition map[string]any) []string {
+	for _, operator := range sortedMapKeys(condition) {
+		values, _ := condition[operator].(map[string]any)
+		for _, key := range sortedMapKeys(values) {
+			keys = appendUnique(keys, key)
+		}
+	}
+
+	return keys
+}
+
+func appendUnique(values []string, value string) []string {
+	if slices.Contains(values, value) {
+		return values
+	}
+
+	return append(values, value)
+}
+
+func (statement PolicyStatement) matches(action string, resource string) bool {
+	actionMatches := matchesAny(statement.Action, action, true)
+	if len(statement.NotAction) > 0 {
+		actionMatches = !matchesAny(statement.NotAction, action, true)
+	}
+
+	// Statements of resource policies may omit the resource, in which case they
+	// apply to the resource the policy is attached to.
+	resourceMatches := len(statement.Resource) == 0 || matchesAny(statement.Resource, resource, false)
+	if len(statement.NotResource) > 0 {
+		resourceMatches = !matchesAny(statement.NotResource, resource, false)
+	}
+
+	return actionMatches && resourceMatches
+}
+
+// namesPrincipal reports whether the statement's AWS principals cover the principal. With
+// accountOnly set only the account itself (ID or root ARN) counts, which is how resource
+// policies delegate access to IAM.
+func (statement PolicyStatement) namesPrincipal(principalArn string, accountOnly bool) bool {
+	accountId := ArnAccountId(principalArn)
+
+	for _, value := range statement.Principal["AWS"] {
+		switch {
+		case accountId != "" && (value == accountId || strings.HasSuffix(value, ":iam::"+accountId+":root")):
+			if accountOnly {
+				return true
+			}
+		case accountOnly:
+			continue
+		case value == "*" || value == principalArn:
+			return true
+		}
+	}
+
+	return false
+}
+
+// EvaluatePolicies combines the decisions of several identity policies: an explicit deny in
+// any of them wins, otherwise one allow is enough. It returns the names of the allowing policies,
+// including those that only allow under conditions, and the condition keys a conditional
+// decision depends on.
+func EvaluatePolicies(policies []NamedPolicy, action string, resource string) (PolicyDecision, []string, []string) {
+	var combined policyTally
+	var allowedBy []string
+
+	for _, policy := range policies {
+		tally := policy.Document.evaluate(action, resource, func(PolicyStatement) bool { return true })
+		if tally.allow || tally.hasConditionalAllow {
+			allowedBy = append(allowedBy, policy.Name)
+		}
+		combined.merge(tally)
+	}
+
+	decision, conditions := combined.result()
+	if decision == PolicyExplicitDeny || decision == PolicyImplicitDeny {
+		return decision, nil, nil
+	}
+
+	return decision, allowedBy, conditions
+}
+
+// ScpLevel holds the SCPs attached to one node (root, OU or account) on the path from the
+// organization root to an account.
+type ScpLevel struct {
+	Target   string
+	Policies []NamedPolicy
+}
+
+// EvaluateScps decides whether the SCPs of an account permit the action: an explicit deny at
+// any level blocks it, and every level with SCPs attached has to allow it. Levels without SCPs,
+// as returned when SCPs aren't enabled, don't restrict anything. The returned reason names
+// the blocking SCP or level, or the SCPs and condition keys a PolicyConditional decision
+// depends on. SCPs never apply to the management account.
+func EvaluateScps(levels []ScpLevel, action string, resource string) (PolicyDecision, string) {
+	var conditionalReasons []string
+
+	for _, level := range levels {
+		if len(level.Policies) == 0 {
+			continue
+		}
+
+		var combined policyTally
+		var conditionalPolicies []string
+		for _, policy := range level.Policies {
+			tally := policy.Document.evaluate(action, resource, func(PolicyStatement) bool { return true })
+			if tally.explicitDeny {
+				return PolicyExplicitDeny, policy.Name + " on " + level.Target
+			}
+			if tally.hasConditionalAllow || tally.hasConditionalDeny {
+				conditionalPolicies = append(conditionalPolicies, policy.Name)
+			}
+			combined.merge(tally)
+		}
+
+		switch decision, conditions := combined.result(); decision {
+		case PolicyImplicitDeny:
+			return PolicyImplicitDeny, "no SCP on " + level.Target + " allows it"
+		case PolicyConditional:
+			conditionalReasons = append(conditionalReasons, fmt.Sprintf("%s on %s, conditions: %s",
+				strings.Join(conditionalPolicies, ", "), level.Target, strings.Join(conditions, ", ")))
+		}
+	}
+
+	if len(conditionalReasons) > 0 {
+		return PolicyConditional, strings.Join(conditionalReasons, "; ")
+	}
+
+	return PolicyAllow, ""
+}
+
+// ArnAccountId returns the account ID field of an ARN, or an empty string if it isn't one.
+func ArnAccountId(value string) string {
+	parts := strings.SplitN(value, ":", 6)
+	if len(parts) < 6 || parts[0] != "arn" {
+		return ""
+	}
+
+	return parts[4]
+}
+
+func matchesAny(patterns []string, value string, caseInsensitive bool) bool {
+	for _, pattern := range patterns {
+		if matchesWildcard(pattern, value, caseInsensitive) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesWildcard matches IAM style patterns, where * matches any sequence of characters and ? any single character.
+func matchesWildcard(pattern string, value string, caseInsensitive bool) bool {
+	expression := regexp.QuoteMeta(pattern)
+	expression = strings.ReplaceAll(expression, `\*`, ".*")
+	expression = strings.ReplaceAll(expression, `\?`, ".")
+	if caseInsensitive {
+		expression = "(?i)" + expression
+	}
+
+	matched, err := regexp.MatchString("^"+expression+"$", value)
+	return err == nil && matched
+}
@@ -0,0 +1,316 @@
+package shared
+
+import (
+	"slices"
+	"testing"
+)
+
+func mustParsePolicy(t *testing.T, document string) PolicyDocument {
+	t.Helper()
+
+	parsed, err := ParsePolicyDocument(document)
+	if err != nil {
+		t.Fatalf("couldn't parse policy: %v", err)
+	}
+
+	return parsed
+}
+
+func TestMatchesWildcard(t *testing.T) {
+	tests := []struct {
+		pattern         string
+		value           string
+		caseInsensitive bool
+		want            bool
+	}{
+		{"*", "s3:GetObject", true, true},
+		{"s3:*", "s3:GetObject", true, true},
+		{"s3:Get*", "s3:PutObject", true, false},
+		{"S3:GETOBJECT", "s3:GetObject", true, true},
+		{"S3:GETOBJECT", "s3:GetObject", false, false},
+		{"s3:Get?bject", "s3:GetObject", true, true},
+		{"s3:Get?bject", "s3:GetObjject", true, false},
+		{"arn:aws:s3:::bucket/*", "arn:aws:s3:::bucket/a/b.txt", false, true},
+		{"arn:aws:s3:::bucket", "arn:aws:s3:::bucket/a", false, false},
+		{"arn:aws:s3:::bucket.name", "arn:aws:s3:::bucketxname", false, false},
+	}
+
+	for _, test := range tests {
+		if got := matchesWildcard(test.pattern, test.value, test.caseInsensitive); got != test.want {
+			t.Errorf("matchesWildcard(%q, %q, %t) = %t, want %t", test.pattern, test.value, test.caseInsensitive, got, test.want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         string
+		action         string
+		resource       string
+		want           PolicyDecision
+		wantConditions []string
+	}{
+		{
+			name:     "allow",
+			policy:   `{"Statement":{"Effect":"Allow","Action":"kms:*","Resource":"*"}}`,
+			action:   "kms:Decrypt",
+			resource: "arn:aws:kms:us-east-1:111111111111:key/1",
+			want:     PolicyAllow,
+		},
+		{
+			name:     "no matching statement",
+			policy:   `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`,
+			action:   "kms:Decrypt",
+			resource: "*",
+			want:     PolicyImplicitDeny,
+		},
+		{
+			name:     "deny wins over allow",
+			policy:   `{"Statement":[{"Effect":"Allow","Action":"*","Resource":"*"},{"Effect":"Deny","Action":"kms:Decrypt","Resource":"*"}]}`,
+			action:   "kms:Decrypt",
+			resource: "*",
+			want:     PolicyExplicitDeny,
+		},
+		{
+			name:     "NotAction excludes the action",
+			policy:   `{"Statement":{"Effect":"Allow","NotAction":"kms:*","Resource":"*"}}`,
+			action:   "kms:Decrypt",
+			resource: "*",
+			want:     PolicyImplicitDeny,
+		},
+		{
+			name:     "NotAction covers other actions",
+			policy:   `{"Statement":{"Effect":"Allow","NotAction":"iam:*","Resource":"*"}}`,
+			action:   "kms:Decrypt",
+			resource: "*",
+			want:     PolicyAllow,
+		},
+		{
+			name:     "NotResource excludes the resource",
+			policy:   `{"Statement":{"Effect":"Allow","Action":"s3:GetObject","NotResource":"arn:aws:s3:::secret/*"}}`,
+			action:   "s3:GetObject",
+			resource: "arn:aws:s3:::secret/key",
+			want:     PolicyImplicitDeny,
+		},
+		{
+			name:     "NotResource covers other resources",
+			policy:   `{"Statement":{"Effect":"Allow","Action":"s3:GetObject","NotResource":"arn:aws:s3:::secret/*"}}`,
+			action:   "s3:GetObject",
+			resource: "arn:aws:s3:::public/key",
+			want:     PolicyAllow,
+		},
+		{
+			name:           "allow with conditions",
+			policy:         `{"Statement":{"Effect":"Allow","Action":"kms:Decrypt","Resource":"*","Condition":{"StringEquals":{"kms:ViaService":"s3.us-east-1.amazonaws.com"}}}}`,
+			action:         "kms:Decrypt",
+			resource:       "*",
+			want:           PolicyConditional,
+			wantConditions: []string{"kms:ViaService"},
+		},
+		{
+			name:     "unconditional allow wins over conditional allow",
+			policy:   `{"Statement":[{"Effect":"Allow","Action":"kms:Decrypt","Resource":"*","Condition":{"Bool":{"aws:SecureTransport":"true"}}},{"Effect":"Allow","Action":"kms:*","Resource":"*"}]}`,
+			action:   "kms:Decrypt",
+			resource: "*",
+			want:     PolicyAllow,
+		},
+		{
+			name:           "deny with conditions",
+			policy:         `{"Statement":[{"Effect":"Allow","Action":"*","Resource":"*"},{"Effect":"Deny","Action":"*","Resource":"*","Condition":{"NotIpAddress":{"aws:SourceIp":"10.0.0.0/8"}}}]}`,
+			action:         "kms:Decrypt",
+			resource:       "*",
+			want:           PolicyConditional,
+			wantConditions: []string{"aws:SourceIp"},
+		},
+		{
+			name:     "deny with conditions and nothing allowed",
+			policy:   `{"Statement":{"Effect":"Deny","Action":"*","Resource":"*","Condition":{"NotIpAddress":{"aws:SourceIp":"10.0.0.0/8"}}}}`,
+			action:   "kms:Decrypt",
+			resource: "*",
+			want:     PolicyImplicitDeny,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, conditions := mustParsePolicy(t, test.policy).Evaluate(test.action, test.resource)
+			if got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+			if !slices.Equal(conditions, test.wantConditions) {
+				t.Errorf("got conditions %v, want %v", conditions, test.wantConditions)
+			}
+		})
+	}
+}
+
+func TestNamesPrincipal(t *testing.T) {
+	const principalArn = "arn:aws:iam::111111111111:role/app"
+
+	tests := []struct {
+		name        string
+		principal   PolicyPrincipal
+		accountOnly bool
+		want        bool
+	}{
+		{"exact ARN", PolicyPrincipal{"AWS": {principalArn}}, false, true},
+		{"everyone", PolicyPrincipal{"AWS": {"*"}}, false, true},
+		{"account ID", PolicyPrincipal{"AWS": {"111111111111"}}, false, false},
+		{"other principal", PolicyPrincipal{"AWS": {"arn:aws:iam::111111111111:role/other"}}, false, false},
+		{"service principal", PolicyPrincipal{"Service": {"lambda.amazonaws.com"}}, false, false},
+		{"account root when account only", PolicyPrincipal{"AWS": {"arn:aws:iam::111111111111:root"}}, true, true},
+		{"account ID when account only", PolicyPrincipal{"AWS": {"111111111111"}}, true, true},
+		{"exact ARN when account only", PolicyPrincipal{"AWS": {principalArn}}, true, false},
+		{"everyone when account only", PolicyPrincipal{"AWS": {"*"}}, true, false},
+		{"other account root", PolicyPrincipal{"AWS": {"arn:aws:iam::222222222222:root"}}, true, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statement := PolicyStatement{Effect: "Allow", Principal: test.principal}
+			if got := statement.namesPrincipal(principalArn, test.accountOnly); got != test.want {
+				t.Errorf("got %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDelegatesToAccount(t *testing.T) {
+	const principalArn = "arn:aws:iam::111111111111:role/app"
+
+	tests := []struct {
+		name           string
+		policy         string
+		want           bool
+		wantConditions []string
+	}{
+		{
+			name:   "default key policy",
+			policy: `{"Statement":[{"Sid":"Enable IAM User Permissions","Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111111111111:root"},"Action":"kms:*","Resource":"*"}]}`,
+			want:   true,
+		},
+		{
+			name:   "only the role",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111111111111:role/app"},"Action":"kms:*","Resource":"*"}]}`,
+			want:   false,
+		},
+		{
+			name:   "other account",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"222222222222"},"Action":"kms:*","Resource":"*"}]}`,
+			want:   false,
+		},
+		{
+			name:   "denied for the account",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"111111111111"},"Action":"kms:*","Resource":"*"},{"Effect":"Deny","Principal":{"AWS":"111111111111"},"Action":"kms:Decrypt","Resource":"*"}]}`,
+			want:   false,
+		},
+		{
+			name:           "delegation with conditions",
+			policy:         `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"111111111111"},"Action":"kms:Decrypt","Resource":"*","Condition":{"StringEquals":{"kms:CallerAccount":"111111111111"}}}]}`,
+			want:           true,
+			wantConditions: []string{"kms:CallerAccount"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, conditions := mustParsePolicy(t, test.policy).DelegatesToAccount("kms:Decrypt", "*", principalArn)
+			if got != test.want {
+				t.Errorf("got %t, want %t", got, test.want)
+			}
+			if !slices.Equal(conditions, test.wantConditions) {
+				t.Errorf("got conditions %v, want %v", conditions, test.wantConditions)
+			}
+		})
+	}
+}
+
+func TestDenyStatementsFor(t *testing.T) {
+	const principalArn = "arn:aws:iam::111111111111:role/app"
+
+	keyPolicy := mustParsePolicy(t, `{"Statement":[
+		{"Effect":"Allow","Principal":{"AWS":"111111111111"},"Action":"kms:*","Resource":"*"},
+		{"Sid":"account","Effect":"Deny","Principal":{"AWS":"arn:aws:iam::111111111111:root"},"Action":"kms:Decrypt","Resource":"*"},
+		{"Sid":"everyone","Effect":"Deny","Principal":{"AWS":"*"},"Action":"kms:*","Resource":"*","Condition":{"Bool":{"aws:SecureTransport":"false"}}},
+		{"Sid":"other","Effect":"Deny","Principal":{"AWS":"arn:aws:iam::111111111111:role/other"},"Action":"kms:*","Resource":"*"}
+	]}`)
+
+	var sids []string
+	for _, statement := range keyPolicy.DenyStatementsFor(principalArn).Statement {
+		sids = append(sids, statement.Sid)
+	}
+	if want := []string{"account", "everyone"}; !slices.Equal(sids, want) {
+		t.Errorf("got statements %v, want %v", sids, want)
+	}
+}
+
+func TestEvaluatePolicies(t *testing.T) {
+	allowAll := NamedPolicy{Name: "admin", Document: mustParsePolicy(t, `{"Statement":{"Effect":"Allow","Action":"*","Resource":"*"}}`)}
+	allowKms := NamedPolicy{Name: "kms", Document: mustParsePolicy(t, `{"Statement":{"Effect":"Allow","Action":"kms:Decrypt","Resource":"*"}}`)}
+	denyKms := NamedPolicy{Name: "deny", Document: mustParsePolicy(t, `{"Statement":{"Effect":"Deny","Action":"kms:*","Resource":"*"}}`)}
+	allowS3 := NamedPolicy{Name: "s3", Document: mustParsePolicy(t, `{"Statement":{"Effect":"Allow","Action":"s3:*","Resource":"*"}}`)}
+	conditionalAllow := NamedPolicy{Name: "mfa", Document: mustParsePolicy(t, `{"Statement":{"Effect":"Allow","Action":"kms:*","Resource":"*","Condition":{"Bool":{"aws:MultiFactorAuthPresent":"true"}}}}`)}
+	conditionalDeny := NamedPolicy{Name: "network", Document: mustParsePolicy(t, `{"Statement":{"Effect":"Deny","Action":"*","Resource":"*","Condition":{"NotIpAddress":{"aws:SourceIp":"10.0.0.0/8"}}}}`)}
+
+	tests := []struct {
+		name           string
+		policies       []NamedPolicy
+		want           PolicyDecision
+		wantAllowedBy  []string
+		wantConditions []string
+	}{
+		{"no policies", nil, PolicyImplicitDeny, nil, nil},
+		{"unrelated policy", []NamedPolicy{allowS3}, PolicyImplicitDeny, nil, nil},
+		{"allowed by several", []NamedPolicy{allowAll, allowS3, allowKms}, PolicyAllow, []string{"admin", "kms"}, nil},
+		{"deny in another policy", []NamedPolicy{allowAll, denyKms}, PolicyExplicitDeny, nil, nil},
+		{"allowed under conditions", []NamedPolicy{conditionalAllow}, PolicyConditional, []string{"mfa"}, []string{"aws:MultiFactorAuthPresent"}},
+		{"unconditional allow in another policy", []NamedPolicy{conditionalAllow, allowKms}, PolicyAllow, []string{"mfa", "kms"}, nil},
+		{"denied under conditions", []NamedPolicy{allowAll, conditionalDeny}, PolicyConditional, []string{"admin"}, []string{"aws:SourceIp"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, allowedBy, conditions := EvaluatePolicies(test.policies, "kms:Decrypt", "*")
+			if got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+			if !slices.Equal(allowedBy, test.wantAllowedBy) {
+				t.Errorf("got allowed by %v, want %v", allowedBy, test.wantAllowedBy)
+			}
+			if !slices.Equal(conditions, test.wantConditions) {
+				t.Errorf("got conditions %v, want %v", conditions, test.wantConditions)
+			}
+		})
+	}
+}
+
+func TestEvaluateScps(t *testing.T) {
+	fullAccess := NamedPolicy{Name: "FullAWSAccess", Document: mustParsePolicy(t, `{"Statement":{"Effect":"Allow","Action":"*","Resource":"*"}}`)}
+	denyKms := NamedPolicy{Name: "DenyKms", Document: mustParsePolicy(t, `{"Statement":{"Effect":"Deny","Action":"kms:*","Resource":"*"}}`)}
+	allowS3 := NamedPolicy{Name: "AllowS3", Document: mustParsePolicy(t, `{"Statement":{"Effect":"Allow","Action":"s3:*","Resource":"*"}}`)}
+	regionLock := NamedPolicy{Name: "RegionLock", Document: mustParsePolicy(t, `{"Statement":{"Effect":"Deny","Action":"*","Resource":"*","Condition":{"StringNotEquals":{"aws:RequestedRegion":"eu-west-1"}}}}`)}
+
+	tests := []struct {
+		name       string
+		levels     []ScpLevel
+		want       PolicyDecision
+		wantReason string
+	}{
+		{"no SCPs", []ScpLevel{{Target: "r-root"}}, PolicyAllow, ""},
+		{"full access", []ScpLevel{{Target: "r-root", Policies: []NamedPolicy{fullAccess}}}, PolicyAllow, ""},
+		{"denied on an OU", []ScpLevel{{Target: "r-root", Policies: []NamedPolicy{fullAccess}}, {Target: "ou-1", Policies: []NamedPolicy{fullAccess, denyKms}}}, PolicyExplicitDeny, "DenyKms on ou-1"},
+		{"not allowed on an OU", []ScpLevel{{Target: "r-root", Policies: []NamedPolicy{fullAccess}}, {Target: "ou-1", Policies: []NamedPolicy{allowS3}}}, PolicyImplicitDeny, "no SCP on ou-1 allows it"},
+		{"denied under conditions", []ScpLevel{{Target: "r-root", Policies: []NamedPolicy{fullAccess, regionLock}}}, PolicyConditional, "RegionLock on r-root, conditions: aws:RequestedRegion"},
+		{"unconditional deny wins", []ScpLevel{{Target: "r-root", Policies: []NamedPolicy{fullAccess, regionLock}}, {Target: "ou-1", Policies: []NamedPolicy{denyKms}}}, PolicyExplicitDeny, "DenyKms on ou-1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, reason := EvaluateScps(test.levels, "kms:Decrypt", "*")
+			if got != test.want || reason != test.wantReason {
+				t.Errorf("got %s (%s), want %s (%s)", got, reason, test.want, test.wantReason)
+			}
+		})
+	}
+}
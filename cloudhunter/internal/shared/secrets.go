@@ -0,0 +1,118 @@
+package shared
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var secretNamePattern = regexp.MustCompile(`(?i)(passw(or)?d|passwd|pwd|secret|token|api[_-]?key|private[_-]?key|access[_-]?key|credential|connection[_-]?string|auth[_-]?(token|key)|authorization)`)
+
+// IsSecretName reports whether a variable, attribute or parameter name suggests it holds a secret.
+func IsSecretName(name string) bool {
+	return secretNamePattern.MatchString(name)
+}
+
+// Generic rules only report lines that no specific rule matched already.
+type secretRule struct {
+	name    string
+	pattern *regexp.Regexp
+	generic bool
+}
+
+var secretRules = []secretRule{
+	{"AWS access key ID", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`), false},
+	{"AWS secret access key", regexp.MustCompile(`(?i)aws.{0,20}(secret|sk).{0,20}['"=:\s]+[A-Za-z0-9/+=]{40}\b`), false},
+	{"Private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`), false},
+	{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`), false},
+	{"Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`), false},
+	{"JSON Web Token", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`), false},
+	{"Credentials in URL", regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s:/@]+:[^\s:/@]+@[^\s/]+`), false},
+	{"Secret assignment", regexp.MustCompile(`(?i)[\w.-]*(passw(or)?d|passwd|secret|token|api[_-]?key)[\w.-]*\s*[:=]\s*['"]?[^\s'"]{4,}`), true},
+}
+
+// SecretMatch is a single secret found by DetectSecrets.
+type SecretMatch struct {
+	Rule  string
+	Line  int
+	Match string
+}
+
+// DetectSecrets scans text line by line for credentials, keys and secret-looking assignments.
+func DetectSecrets(text string) []SecretMatch {
+	var matches []SecretMatch
+
+	for number, line := range strings.Split(text, "\n") {
+		lineMatched := false
+		for _, rule := range secretRules {
+			if rule.generic && lineMatched {
+				continue
+			}
+			for _, match := range rule.pattern.FindAllString(line, -1) {
+				matches = append(matches, SecretMatch{Rule: rule.name, Line: number + 1, Match: match})
+				lineMatched = true
+			}
+		}
+	}
+
+	return matches
+}
+
+// DecodeUserData decodes base64 encoded EC2 user data, decompressing it when it was gzipped.
+func DecodeUserData(encoded string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", err
+	}
+
+	if bytes.HasPrefix(data, gzipMagic) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", err
+		}
+		defer reader.Close()
+
+		data, err = io.ReadAll(reader)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return string(data), nil
+}
+
+// FormatSecretValue renders a secret value for a report. JSON objects are printed with
+// their key names, and when redact is set only the key names are kept, so a report
+// shows what a secret contains without leaking the plaintext.
+func FormatSecretValue(value string, redact bool) string {
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		if redact {
+			return fmt.Sprintf("<redacted, %d characters>", len(value))
+		}
+		return value
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var out strings.Builder
+	for _, key := range keys {
+		if redact {
+			fmt.Fprintf(&out, "\n  %s: <redacted>", key)
+		} else {
+			fmt.Fprintf(&out, "\n  %s: %v", key, fields[key])
+		}
+	}
+
+	return fmt.Sprintf("JSON object with %d keys", len(keys)) + out.String()
+}
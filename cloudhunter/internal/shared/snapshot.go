@@ -0,0 +1,93 @@
+package shared
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+)
+
+// BucketSnapshot is a point in time listing of a bucket, saved between visits
+// so that changes can be spotted later.
+type BucketSnapshot struct {
+	Bucket  string           `json:"bucket"`
+	TakenAt time.Time        `json:"taken_at"`
+	Objects []SnapshotObject `json:"objects"`
+}
+
+type SnapshotObject struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// SnapshotDiff lists the objects that changed between two snapshots.
+// Modified holds the newer version of each object.
+type SnapshotDiff struct {
+	Added    []SnapshotObject
+	Removed  []SnapshotObject
+	Modified []SnapshotObject
+}
+
+// Changed returns the added and modified objects, i.e. the ones worth fetching again.
+func (diff SnapshotDiff) Changed() []SnapshotObject {
+	return append(append([]SnapshotObject{}, diff.Added...), diff.Modified...)
+}
+
+func SaveSnapshot(path string, snapshot BucketSnapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
+
+func LoadSnapshot(path string) (BucketSnapshot, error) {
+	var snapshot BucketSnapshot
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snapshot, err
+	}
+
+	err = json.Unmarshal(data, &snapshot)
+	return snapshot, err
+}
+
+// DiffSnapshots compares two snapshots of the same bucket. Objects are considered
+// modified when their ETag, size or last modified date differ.
+func DiffSnapshots(older BucketSnapshot, newer BucketSnapshot) SnapshotDiff {
+	var diff SnapshotDiff
+
+	olderObjects := make(map[string]SnapshotObject, len(older.Objects))
+	for _, object := range older.Objects {
+		olderObjects[object.Key] = object
+	}
+
+	newerKeys := make(map[string]bool, len(newer.Objects))
+	for _, object := range newer.Objects {
+		newerKeys[object.Key] = true
+
+		previous, ok := olderObjects[object.Key]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, object)
+		case previous.ETag != object.ETag || previous.Size != object.Size || !previous.LastModified.Equal(object.LastModified):
+			diff.Modified = append(diff.Modified, object)
+		}
+	}
+
+	for _, object := range older.Objects {
+		if !newerKeys[object.Key] {
+			diff.Removed = append(diff.Removed, object)
+		}
+	}
+
+	for _, objects := range [][]SnapshotObject{diff.Added, diff.Removed, diff.Modified} {
+		sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	}
+
+	return diff
+}
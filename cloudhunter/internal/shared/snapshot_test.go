@@ -0,0 +1,110 @@
+package shared
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	modified := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	older := BucketSnapshot{Bucket: "acme", Objects: []SnapshotObject{
+		{Key: "same.txt", Size: 10, ETag: "a", LastModified: modified},
+		{Key: "etag.txt", Size: 10, ETag: "a", LastModified: modified},
+		{Key: "size.txt", Size: 10, ETag: "a", LastModified: modified},
+		{Key: "date.txt", Size: 10, ETag: "a", LastModified: modified},
+		{Key: "removed-b.txt", Size: 1, ETag: "b", LastModified: modified},
+		{Key: "removed-a.txt", Size: 1, ETag: "b", LastModified: modified},
+	}}
+	newer := BucketSnapshot{Bucket: "acme", Objects: []SnapshotObject{
+		{Key: "same.txt", Size: 10, ETag: "a", LastModified: modified.In(time.FixedZone("CET", 3600))},
+		{Key: "etag.txt", Size: 10, ETag: "b", LastModified: modified},
+		{Key: "size.txt", Size: 11, ETag: "a", LastModified: modified},
+		{Key: "date.txt", Size: 10, ETag: "a", LastModified: modified.Add(time.Second)},
+		{Key: "added-b.txt", Size: 2, ETag: "c", LastModified: modified},
+		{Key: "added-a.txt", Size: 2, ETag: "c", LastModified: modified},
+	}}
+
+	diff := DiffSnapshots(older, newer)
+
+	assertKeys(t, "added", diff.Added, []string{"added-a.txt", "added-b.txt"})
+	assertKeys(t, "removed", diff.Removed, []string{"removed-a.txt", "removed-b.txt"})
+	assertKeys(t, "modified", diff.Modified, []string{"date.txt", "etag.txt", "size.txt"})
+	assertKeys(t, "changed", diff.Changed(), []string{"added-a.txt", "added-b.txt", "date.txt", "etag.txt", "size.txt"})
+
+	if diff.Modified[2].Size != 11 {
+		t.Errorf("modified objects should hold the newer version, got size %d", diff.Modified[2].Size)
+	}
+}
+
+func TestDiffSnapshotsEmpty(t *testing.T) {
+	diff := DiffSnapshots(BucketSnapshot{}, BucketSnapshot{})
+	if len(diff.Added)+len(diff.Removed)+len(diff.Modified) != 0 {
+		t.Errorf("expected no differences between empty snapshots, got %+v", diff)
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	snapshot := BucketSnapshot{Bucket: "acme", TakenAt: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), Objects: []SnapshotObject{
+		{Key: "a.txt", Size: 1, ETag: "x", LastModified: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}}
+
+	if err := SaveSnapshot(path, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := DiffSnapshots(snapshot, loaded); len(diff.Added)+len(diff.Removed)+len(diff.Modified) != 0 {
+		t.Errorf("loaded snapshot differs from the saved one: %+v", diff)
+	}
+}
+
+func TestContainedPath(t *testing.T) {
+	root := t.TempDir()
+
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"a.txt", true},
+		{"dir/b.txt", true},
+		{"dir/../c.txt", true},
+		{"/etc/passwd", true},
+		{"../outside.txt", false},
+		{"dir/../../outside.txt", false},
+		{"../../.bashrc", false},
+	}
+
+	for _, test := range tests {
+		path, err := ContainedPath(root, test.name)
+		if (err == nil) != test.valid {
+			t.Errorf("ContainedPath(%q) error = %v, want valid %t", test.name, err, test.valid)
+			continue
+		}
+		if err == nil {
+			if !strings.HasPrefix(path, root+string(os.PathSeparator)) {
+				t.Errorf("ContainedPath(%q) = %s, outside of %s", test.name, path, root)
+			}
+		}
+	}
+}
+
+func assertKeys(t *testing.T, kind string, objects []SnapshotObject, expected []string) {
+	t.Helper()
+
+	if len(objects) != len(expected) {
+		t.Fatalf("expected %d %s objects, got %d: %+v", len(expected), kind, len(objects), objects)
+	}
+	for i, object := range objects {
+		if object.Key != expected[i] {
+			t.Errorf("%s object %d: expected %s, got %s", kind, i, expected[i], object.Key)
+		}
+	}
+}
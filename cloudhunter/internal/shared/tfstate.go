@@ -0,0 +1,447 @@
+package shared
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	StateKindTerraform      = "terraform"
+	StateKindCloudFormation = "cloudformation"
+)
+
+var arnPattern = regexp.MustCompile(`arn:aws[a-z-]*:[a-z0-9-]+:[a-z0-9-]*:[0-9]{0,12}:[A-Za-z0-9_+=,.@/:*-]+`)
+
+// credentialAttributes are attribute names that hold AWS credentials rather than generic secrets.
+var credentialAttributes = map[string]bool{
+	"access_key":        true,
+	"secret_key":        true,
+	"secret":            true,
+	"secret_access_key": true,
+	"session_token":     true,
+	"token":             true,
+}
+
+// StateReport is what was extracted from a single Terraform state file or CloudFormation template.
+type StateReport struct {
+	Source      string        `json:"source"`
+	Kind        string        `json:"kind"`
+	Resources   []string      `json:"resources"`
+	Outputs     []StateOutput `json:"outputs,omitempty"`
+	Sensitive   []StateSecret `json:"sensitive,omitempty"`
+	Credentials []StateSecret `json:"credentials,omitempty"`
+	Arns        []string      `json:"arns,omitempty"`
+}
+
+type StateOutput struct {
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	Sensitive bool   `json:"sensitive"`
+}
+
+type StateSecret struct {
+	Resource  string `json:"resource"`
+	Attribute string `json:"attribute"`
+	Value     string `json:"value"`
+}
+
+// EnumerationSeeds are names pulled out of harvested ARNs that can be fed into further iam and s3 commands.
+type EnumerationSeeds struct {
+	Accounts []string `json:"accounts"`
+	Roles    []string `json:"roles"`
+	Users    []string `json:"users"`
+	Buckets  []string `json:"buckets"`
+}
+
+// StateFileKind reports whether the object key looks like a Terraform state file or a
+// CloudFormation template, and which one.
+func StateFileKind(key string) (string, bool) {
+	lower := strings.ToLower(key)
+
+	if strings.HasSuffix(lower, ".tfstate") || strings.HasSuffix(lower, ".tfstate.backup") {
+		return StateKindTerraform, true
+	}
+
+	for _, suffix := range []string{".template", ".template.json", ".template.yaml", ".template.yml", ".cfn.json", ".cfn.yaml", ".cfn.yml"} {
+		if strings.HasSuffix(lower, suffix) {
+			return StateKindCloudFormation, true
+		}
+	}
+
+	if strings.HasSuffix(lower, ".json") || strings.HasSuffix(lower, ".yaml") || strings.HasSuffix(lower, ".yml") {
+		if strings.Contains(lower, "cloudformation") || strings.Contains(lower, "cfn") {
+			return StateKindCloudFormation, true
+		}
+	}
+
+	return "", false
+}
+
+// ParseTerraformState extracts resources, outputs, sensitive attributes, credentials and
+// referenced ARNs from a Terraform state file (format version 4).
+func ParseTerraformState(data []byte) (StateReport, error) {
+	report := StateReport{Kind: StateKindTerraform}
+
+	var state struct {
+		Outputs map[string]struct {
+			Value     any  `json:"value"`
+			Sensitive bool `json:"sensitive"`
+		} `json:"outputs"`
+		Resources []struct {
+			Mode      string `json:"mode"`
+			Type      string `json:"type"`
+			Name      string `json:"name"`
+			Module    string `json:"module"`
+			Instances []struct {
+				Attributes          map[string]any    `json:"attributes"`
+				SensitiveAttributes []json.RawMessage `json:"sensitive_attributes"`
+			} `json:"instances"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return report, err
+	}
+
+	for name, output := range state.Outputs {
+		report.Outputs = append(report.Outputs, StateOutput{
+			Name:      name,
+			Value:     stringifyValue(output.Value),
+			Sensitive: output.Sensitive,
+		})
+	}
+	sort.Slice(report.Outputs, func(i, j int) bool { return report.Outputs[i].Name < report.Outputs[j].Name })
+
+	for _, resource := range state.Resources {
+		address := resource.Type + "." + resource.Name
+		if resource.Mode == "data" {
+			address = "data." + address
+		}
+		if resource.Module != "" {
+			address = resource.Module + "." + address
+		}
+		report.Resources = append(report.Resources, address)
+
+		for _, instance := range resource.Instances {
+			sensitive := sensitiveAttributePaths(instance.SensitiveAttributes)
+
+			// Nested blocks are walked too, e.g. environment[0].variables of aws_lambda_function.
+			walkLeaves("", "", instance.Attributes, func(attribute string, name string, value string) {
+				secret := StateSecret{Resource: address, Attribute: attribute, Value: value}
+				switch {
+				case resource.Type == "aws_iam_access_key" && (attribute == "id" || attribute == "secret" || attribute == "ses_smtp_password_v4"):
+					report.Credentials = append(report.Credentials, secret)
+				case credentialAttributes[name]:
+					report.Credentials = append(report.Credentials, secret)
+				case isSensitivePath(sensitive, attribute) || IsSecretName(name):
+					report.Sensitive = append(report.Sensitive, secret)
+				}
+			})
+		}
+	}
+
+	report.Arns = ExtractArns(data)
+	return report, nil
+}
+
+// ParseCloudFormationTemplate extracts resources, outputs, secret-looking parameters and
+// properties and referenced ARNs from a JSON or YAML CloudFormation template.
+func ParseCloudFormationTemplate(data []byte) (StateReport, error) {
+	report := StateReport{Kind: StateKindCloudFormation}
+
+	// Decoding into a node keeps short-form intrinsic functions such as !Ref parseable.
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return report, err
+	}
+
+	budget := maxYamlNodes
+	value, err := yamlNodeValue(&document, &budget)
+	if err != nil {
+		return report, err
+	}
+
+	template, ok := value.(map[string]any)
+	if !ok {
+		return report, errors.New("template is not a mapping")
+	}
+
+	resources, _ := template["Resources"].(map[string]any)
+	if _, hasVersion := template["AWSTemplateFormatVersion"]; !hasVersion && len(resources) == 0 {
+		return report, errors.New("not a CloudFormation template")
+	}
+
+	for _, logicalId := range sortedMapKeys(resources) {
+		resource, _ := resources[logicalId].(map[string]any)
+		report.Resources = append(report.Resources, fmt.Sprintf("%s (%s)", logicalId, stringifyValue(resource["Type"])))
+
+		walkLeaves("", "", resource["Properties"], func(attribute string, name string, value string) {
+			if IsSecretName(name) {
+				report.Sensitive = append(report.Sensitive, StateSecret{Resource: logicalId, Attribute: attribute, Value: value})
+			}
+		})
+	}
+
+	parameters, _ := template["Parameters"].(map[string]any)
+	for _, name := range sortedMapKeys(parameters) {
+		parameter, _ := parameters[name].(map[string]any)
+		defaultValue, hasDefault := parameter["Default"]
+		if !hasDefault {
+			continue
+		}
+
+		noEcho := strings.EqualFold(stringifyValue(parameter["NoEcho"]), "true")
+		if noEcho || IsSecretName(name) {
+			report.Sensitive = append(report.Sensitive, StateSecret{
+				Resource:  "Parameters",
+				Attribute: name,
+				Value:     stringifyValue(defaultValue),
+			})
+		}
+	}
+
+	outputs, _ := template["Outputs"].(map[string]any)
+	for _, name := range sortedMapKeys(outputs) {
+		output, _ := outputs[name].(map[string]any)
+		report.Outputs = append(report.Outputs, StateOutput{Name: name, Value: stringifyValue(output["Value"])})
+	}
+
+	report.Arns = ExtractArns(data)
+	return report, nil
+}
+
+// ExtractArns returns every distinct ARN found in data.
+func ExtractArns(data []byte) []string {
+	seen := make(map[string]bool)
+	var arns []string
+
+	for _, match := range arnPattern.FindAll(data, -1) {
+		arn := strings.TrimRight(string(match), ".,:")
+		if !seen[arn] {
+			seen[arn] = true
+			arns = append(arns, arn)
+		}
+	}
+	sort.Strings(arns)
+
+	return arns
+}
+
+// SeedsFromArns collects account IDs, IAM role and user names and bucket names from ARNs.
+func SeedsFromArns(arns []string) EnumerationSeeds {
+	accounts := make(map[string]bool)
+	roles := make(map[string]bool)
+	users := make(map[string]bool)
+	buckets := make(map[string]bool)
+
+	for _, arn := range arns {
+		parts := strings.SplitN(arn, ":", 6)
+		if len(parts) < 6 {
+			continue
+		}
+		service, account, resource := parts[2], parts[4], parts[5]
+
+		if len(account) == 12 {
+			accounts[account] = true
+		}
+
+		switch service {
+		case "iam":
+			segments := strings.Split(resource, "/")
+			name := segments[len(segments)-1]
+			if name == "" || strings.Contains(name, "*") {
+				continue
+			}
+			switch segments[0] {
+			case "role":
+				roles[name] = true
+			case "user":
+				users[name] = true
+			}
+		case "s3":
+			bucket := strings.SplitN(resource, "/", 2)[0]
+			if bucket != "" && !strings.Contains(bucket, "*") {
+				buckets[bucket] = true
+			}
+		}
+	}
+
+	return EnumerationSeeds{
+		Accounts: sortedSet(accounts),
+		Roles:    sortedSet(roles),
+		Users:    sortedSet(users),
+		Buckets:  sortedSet(buckets),
+	}
+}
+
+// walkLeaves calls fn for every non-empty scalar below value with its path, such as
+// "environment[0].variables.DB_PASSWORD", and the name of the closest mapping key, which is
+// what secret names are matched against.
+func walkLeaves(path string, name string, value any, fn func(path string, name string, value string)) {
+	switch typed := value.(type) {
+	case map[string]any:
+		for _, key := range sortedMapKeys(typed) {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			walkLeaves(child, key, typed[key], fn)
+		}
+	case []any:
+		for i, item := range typed {
+			walkLeaves(fmt.Sprintf("%s[%d]", path, i), name, item, fn)
+		}
+	default:
+		if text := stringifyValue(typed); text != "" && text != "null" {
+			fn(path, name, text)
+		}
+	}
+}
+
+// sensitiveAttributePaths returns the attribute paths marked as sensitive, in the format of
+// walkLeaves. Each entry is a list of steps such as [{"type":"get_attr","value":"environment"},
+// {"type":"index","value":{"value":0,"type":"number"}}].
+func sensitiveAttributePaths(paths []json.RawMessage) map[string]bool {
+	sensitive := make(map[string]bool)
+
+	for _, raw := range paths {
+		var steps []struct {
+			Type  string          `json:"type"`
+			Value json.RawMessage `json:"value"`
+		}
+		if err := json.Unmarshal(raw, &steps); err != nil || len(steps) == 0 {
+			continue
+		}
+
+		var path strings.Builder
+		for _, step := range steps {
+			if step.Type == "get_attr" {
+				var name string
+				if json.Unmarshal(step.Value, &name) == nil {
+					if path.Len() > 0 {
+						path.WriteByte('.')
+					}
+					path.WriteString(name)
+				}
+				continue
+			}
+
+			var index struct {
+				Value any `json:"value"`
+			}
+			if json.Unmarshal(step.Value, &index) != nil {
+				continue
+			}
+			switch key := index.Value.(type) {
+			case float64:
+				fmt.Fprintf(&path, "[%d]", int(key))
+			case string:
+				path.WriteByte('.')
+				path.WriteString(key)
+			}
+		}
+		sensitive[path.String()] = true
+	}
+
+	return sensitive
+}
+
+// isSensitivePath reports whether the attribute, or a block or map it is part of, is marked
+// as sensitive.
+func isSensitivePath(sensitive map[string]bool, attribute string) bool {
+	for path := range sensitive {
+		if attribute == path || strings.HasPrefix(attribute, path+".") || strings.HasPrefix(attribute, path+"[") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// maxYamlNodes bounds how many nodes a template may expand to. Aliases are expanded in place,
+// so a small file with nested aliases could otherwise grow exponentially.
+const maxYamlNodes = 100000
+
+var errYamlTooLarge = fmt.Errorf("template expands to more than %d nodes", maxYamlNodes)
+
+// yamlNodeValue converts a YAML node into plain maps, slices and strings. Tags such as
+// !Ref or !Sub are dropped and only the tagged value is kept. budget is decremented for
+// every converted node, and conversion fails once it runs out.
+func yamlNodeValue(node *yaml.Node, budget *int) (any, error) {
+	if *budget <= 0 {
+		return nil, errYamlTooLarge
+	}
+	*budget--
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlNodeValue(node.Content[0], budget)
+	case yaml.MappingNode:
+		values := make(map[string]any, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := yamlNodeValue(node.Content[i+1], budget)
+			if err != nil {
+				return nil, err
+			}
+			values[node.Content[i].Value] = value
+		}
+		return values, nil
+	case yaml.SequenceNode:
+		values := make([]any, 0, len(node.Content))
+		for _, child := range node.Content {
+			value, err := yamlNodeValue(child, budget)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case yaml.AliasNode:
+		return yamlNodeValue(node.Alias, budget)
+	default:
+		return node.Value, nil
+	}
+}
+
+func stringifyValue(value any) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	default:
+		data, err := json.Marshal(typed)
+		if err != nil {
+			return fmt.Sprint(typed)
+		}
+		return string(data)
+	}
+}
+
+func sortedMapKeys(values map[string]any) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedSet(values map[string]bool) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
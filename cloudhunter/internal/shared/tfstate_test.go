@@ -0,0 +1,119 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseCloudFormationTemplateAliasBomb(t *testing.T) {
+	var template strings.Builder
+	template.WriteString("AWSTemplateFormatVersion: '2010-09-09'\n")
+	template.WriteString("a0: &a0 [lol, lol, lol, lol, lol, lol, lol, lol, lol, lol]\n")
+	for i := 1; i < 10; i++ {
+		alias := fmt.Sprintf("*a%d", i-1)
+		fmt.Fprintf(&template, "a%d: &a%d [%s]\n", i, i, strings.Repeat(alias+", ", 9)+alias)
+	}
+
+	if _, err := ParseCloudFormationTemplate([]byte(template.String())); err == nil {
+		t.Fatal("expected an error for a template with exponential alias expansion")
+	}
+}
+
+func TestParseCloudFormationTemplateAliases(t *testing.T) {
+	template := `AWSTemplateFormatVersion: '2010-09-09'
+Defaults: &defaults
+  Type: AWS::S3::Bucket
+Resources:
+  Logs: *defaults
+`
+
+	report, err := ParseCloudFormationTemplate([]byte(template))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Resources) != 1 || report.Resources[0] != "Logs (AWS::S3::Bucket)" {
+		t.Errorf("got resources %v", report.Resources)
+	}
+}
+
+func TestParseTerraformStateNestedAttributes(t *testing.T) {
+	state := `{
+  "version": 4,
+  "resources": [{
+    "mode": "managed", "type": "aws_lambda_function", "name": "api",
+    "instances": [{
+      "attributes": {
+        "function_name": "api",
+        "author": "ops",
+        "environment": [{"variables": {"DB_PASSWORD": "hunter2", "STAGE": "prod"}}],
+        "tags": {"owner": "ops"},
+        "layers": [{"secret_key": "abc"}],
+        "vpc_config": [{"subnet_ids": ["subnet-1"]}]
+      },
+      "sensitive_attributes": [[
+        {"type": "get_attr", "value": "vpc_config"},
+        {"type": "index", "value": {"value": 0, "type": "number"}},
+        {"type": "get_attr", "value": "subnet_ids"}
+      ]]
+    }]
+  }]
+}`
+
+	report, err := ParseTerraformState([]byte(state))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var sensitive []string
+	for _, secret := range report.Sensitive {
+		sensitive = append(sensitive, secret.Attribute+"="+secret.Value)
+	}
+	if want := "environment[0].variables.DB_PASSWORD=hunter2,vpc_config[0].subnet_ids[0]=subnet-1"; strings.Join(sensitive, ",") != want {
+		t.Errorf("got sensitive %v, want %s", sensitive, want)
+	}
+	if len(report.Credentials) != 1 || report.Credentials[0].Attribute != "layers[0].secret_key" {
+		t.Errorf("got credentials %v", report.Credentials)
+	}
+}
+
+func TestParseCloudFormationTemplateLists(t *testing.T) {
+	template := `Resources:
+  Task:
+    Type: AWS::ECS::TaskDefinition
+    Properties:
+      ContainerDefinitions:
+        - Name: app
+          Environment:
+            - Name: STAGE
+              Value: prod
+          Secrets:
+            - ApiKey: sk-live-1
+`
+
+	report, err := ParseCloudFormationTemplate([]byte(template))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Sensitive) != 1 || report.Sensitive[0].Attribute != "ContainerDefinitions[0].Secrets[0].ApiKey" {
+		t.Errorf("got sensitive %v", report.Sensitive)
+	}
+}
+
+func TestIsSecretName(t *testing.T) {
+	tests := map[string]bool{
+		"DB_PASSWORD":   true,
+		"auth_token":    true,
+		"AuthKey":       true,
+		"Authorization": true,
+		"author":        false,
+		"authority":     false,
+		"STAGE":         false,
+	}
+
+	for name, want := range tests {
+		if got := IsSecretName(name); got != want {
+			t.Errorf("IsSecretName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
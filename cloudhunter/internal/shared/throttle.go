@@ -0,0 +1,132 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// Request pacing settings, set from the global --rate, --jitter and --max-retries flags.
+var (
+	RequestRate   float64
+	RequestJitter time.Duration
+	MaxRetries    = 2
+)
+
+// requestStats counts the API operations sent and the retries made for them.
+var requestStats struct {
+	operations atomic.Int64
+	retries    atomic.Int64
+}
+
+type attemptCounterKey struct{}
+
+// requestLimiter spaces requests evenly at RequestRate per second across every client.
+var requestLimiter struct {
+	mu   sync.Mutex
+	next time.Time
+}
+
+// waitForTurn blocks until the next request may be sent, adding a random jitter on top.
+func waitForTurn(ctx context.Context) error {
+	delay := time.Duration(0)
+
+	if RequestRate > 0 {
+		interval := time.Duration(float64(time.Second) / RequestRate)
+
+		requestLimiter.mu.Lock()
+		now := time.Now()
+		if requestLimiter.next.Before(now) {
+			requestLimiter.next = now
+		}
+		delay = requestLimiter.next.Sub(now)
+		requestLimiter.next = requestLimiter.next.Add(interval)
+		requestLimiter.mu.Unlock()
+	}
+
+	if RequestJitter > 0 {
+		delay += rand.N(RequestJitter)
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// countAttemptsMiddleware runs once per operation and gives its attempts a counter.
+var countAttemptsMiddleware = middleware.FinalizeMiddlewareFunc("CountAttempts",
+	func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+		return next.HandleFinalize(middleware.WithStackValue(ctx, attemptCounterKey{}, new(int)), in)
+	})
+
+// paceAttemptMiddleware runs after the retry middleware, so every attempt, retries
+// included, is paced and counted.
+var paceAttemptMiddleware = middleware.FinalizeMiddlewareFunc("PaceAttempt",
+	func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+		if err := waitForTurn(ctx); err != nil {
+			return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+		}
+
+		if attempts, ok := middleware.GetStackValue(ctx, attemptCounterKey{}).(*int); ok {
+			*attempts++
+			if *attempts == 1 {
+				requestStats.operations.Add(1)
+			} else {
+				requestStats.retries.Add(1)
+			}
+		}
+
+		return next.HandleFinalize(ctx, in)
+	})
+
+// applyPacingOptions sets up adaptive retries, which back off and slow down on Throttling
+// and SlowDown errors, and paces every request of clients built from the config.
+func applyPacingOptions(cfg *aws.Config) {
+	cfg.Retryer = func() aws.Retryer {
+		return retry.NewAdaptiveMode(func(options *retry.AdaptiveModeOptions) {
+			options.StandardOptions = append(options.StandardOptions, func(standard *retry.StandardOptions) {
+				standard.MaxAttempts = MaxRetries + 1
+			})
+		})
+	}
+
+	cfg.APIOptions = append(cfg.APIOptions, func(stack *middleware.Stack) error {
+		// Presigned requests are sent by whoever uses the URL, not by this run.
+		if isPresignStack(stack) {
+			return nil
+		}
+		if err := stack.Finalize.Add(countAttemptsMiddleware, middleware.Before); err != nil {
+			return err
+		}
+		if _, ok := stack.Finalize.Get("Retry"); ok {
+			return stack.Finalize.Insert(paceAttemptMiddleware, "Retry", middleware.After)
+		}
+		return stack.Finalize.Add(paceAttemptMiddleware, middleware.After)
+	})
+}
+
+// PrintRunSummary prints how many API requests the run made and how many of them were retries.
+func PrintRunSummary() {
+	operations := requestStats.operations.Load()
+	if operations == 0 {
+		return
+	}
+
+	fmt.Printf("[!] Run summary: %d API calls, %d retries\n", operations, requestStats.retries.Load())
+}
@@ -0,0 +1,165 @@
+package shared
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// Transport settings, set from the global --proxy, --ca-bundle, --insecure-tls and --user-agent flags.
+var (
+	ProxyUrl    string
+	CaBundle    string
+	InsecureTLS bool
+	UserAgent   string
+)
+
+// UserAgentPresets are the --user-agent values that mimic common AWS clients, so requests
+// blend in with regular traffic in CloudTrail's userAgent field.
+var UserAgentPresets = map[string]string{
+	"aws-cli": "aws-cli/2.27.49 md/awscrt#0.26.1 ua/2.1 os/linux#6.8.0-1029-aws md/arch#x86_64 lang/python#3.13.4 md/pyimpl#CPython cfg/retry-mode#standard md/installer#exe md/distrib#ubuntu.24 md/prompt#off",
+	"boto3":   "Boto3/1.38.46 md/Botocore#1.38.46 ua/2.1 os/linux#6.8.0-1029-aws md/arch#x86_64 lang/python#3.12.3 md/pyimpl#CPython cfg/retry-mode#legacy Botocore/1.38.46",
+	"console": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/138.0.0.0 Safari/537.36",
+}
+
+// UserAgentHeader returns the User-Agent requests should be sent with, resolving presets,
+// or an empty string to keep the SDK's own.
+func UserAgentHeader() string {
+	if preset, ok := UserAgentPresets[strings.ToLower(UserAgent)]; ok {
+		return preset
+	}
+
+	return UserAgent
+}
+
+// ValidateTransportOptions checks the proxy URL and CA bundle before any client is built.
+func ValidateTransportOptions() error {
+	if ProxyUrl != "" {
+		if _, err := parseProxyUrl(ProxyUrl); err != nil {
+			return err
+		}
+	}
+
+	if CaBundle != "" {
+		if _, err := loadCaBundle(CaBundle); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseProxyUrl(value string) (*url.URL, error) {
+	proxy, err := url.Parse(value)
+	if err != nil || proxy.Host == "" {
+		return nil, fmt.Errorf("[-] Invalid proxy URL %q, expected e.g. http://127.0.0.1:8080 or socks5://127.0.0.1:1080", value)
+	}
+
+	switch proxy.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return proxy, nil
+	}
+
+	return nil, fmt.Errorf("[-] Unsupported proxy scheme %q, expected http, https, socks5 or socks5h", proxy.Scheme)
+}
+
+// loadCaBundle returns the system root certificates extended with the PEM certificates in the file.
+func loadCaBundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("[-] Failed to read CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("[-] No PEM certificates found in CA bundle %s", path)
+	}
+
+	return pool, nil
+}
+
+// transportLoadOptions returns the config load option installing an HTTP client that honours
+// --proxy, --ca-bundle and --insecure-tls. It is passed to LoadDefaultConfig so credential
+// providers (STS, SSO) use the same transport as the service clients.
+func transportLoadOptions() ([]func(*config.LoadOptions) error, error) {
+	if ProxyUrl == "" && CaBundle == "" && !InsecureTLS {
+		return nil, nil
+	}
+
+	var proxy *url.URL
+	if ProxyUrl != "" {
+		parsed, err := parseProxyUrl(ProxyUrl)
+		if err != nil {
+			return nil, err
+		}
+		proxy = parsed
+	}
+
+	var roots *x509.CertPool
+	if CaBundle != "" {
+		pool, err := loadCaBundle(CaBundle)
+		if err != nil {
+			return nil, err
+		}
+		roots = pool
+	}
+
+	client := awshttp.NewBuildableClient().WithTransportOptions(func(transport *http.Transport) {
+		if proxy != nil {
+			// net/http dials socks5 proxies itself, so both kinds only need the URL.
+			transport.Proxy = http.ProxyURL(proxy)
+		}
+
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		if roots != nil {
+			transport.TLSClientConfig.RootCAs = roots
+		}
+		if InsecureTLS {
+			transport.TLSClientConfig.InsecureSkipVerify = true
+		}
+	})
+
+	return []func(*config.LoadOptions) error{config.WithHTTPClient(client)}, nil
+}
+
+// userAgentMiddleware replaces the User-Agent the SDK has built, and drops the SDK's own
+// X-Amz-User-Agent header, which would give the Go SDK away.
+var userAgentMiddleware = middleware.BuildMiddlewareFunc("OverrideUserAgent",
+	func(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+		if request, ok := in.Request.(*smithyhttp.Request); ok {
+			request.Header.Set("User-Agent", UserAgentHeader())
+			request.Header.Del("X-Amz-User-Agent")
+		}
+
+		return next.HandleBuild(ctx, in)
+	})
+
+// applyUserAgentOptions sends every request of clients built from the config with --user-agent.
+func applyUserAgentOptions(cfg *aws.Config) {
+	if UserAgentHeader() == "" {
+		return
+	}
+
+	cfg.APIOptions = append(cfg.APIOptions, func(stack *middleware.Stack) error {
+		if _, ok := stack.Build.Get("UserAgent"); ok {
+			return stack.Build.Insert(userAgentMiddleware, "UserAgent", middleware.After)
+		}
+		return stack.Build.Add(userAgentMiddleware, middleware.After)
+	})
+}
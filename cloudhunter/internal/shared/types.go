@@ -1,7 +1,181 @@
 package shared
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 type S3Node struct {
 	Name     string
+	Key      string
 	IsFolder bool
 	Children []*S3Node
+
+	Size         int64
+	LastModified time.Time
+	StorageClass string
+	Owner        string
+	ETag         string
+	Metadata     *S3ObjectMetadata
+}
+
+// TotalSize returns the size of the object, or the combined size of every object below a folder.
+func (node *S3Node) TotalSize() int64 {
+	if !node.IsFolder {
+		return node.Size
+	}
+
+	var total int64
+	for _, child := range node.Children {
+		total += child.TotalSize()
+	}
+
+	return total
+}
+
+// S3ObjectMetadata holds the per-object details that are only available through
+// HeadObject and GetObjectTagging.
+type S3ObjectMetadata struct {
+	UserMetadata         map[string]string
+	Tags                 map[string]string
+	ServerSideEncryption string
+	KMSKeyId             string
+	ObjectLockMode       string
+	ObjectLockRetainDate *time.Time
+	ObjectLockLegalHold  string
+}
+
+// BucketStatus describes how much of a bucket is exposed to an anonymous caller.
+type BucketStatus int
+
+const (
+	BucketNotFound BucketStatus = iota
+	BucketPrivate
+	BucketListable
+	BucketReadable
+)
+
+func (status BucketStatus) String() string {
+	switch status {
+	case BucketNotFound:
+		return "not found"
+	case BucketPrivate:
+		return "exists-private"
+	case BucketListable:
+		return "listable"
+	case BucketReadable:
+		return "readable"
+	default:
+		return "unknown"
+	}
+}
+
+type BucketDiscoveryResult struct {
+	Name   string
+	Region string
+	Status BucketStatus
+}
+
+// PermissionResult is the outcome of testing a single API action against a resource.
+type PermissionResult int
+
+const (
+	PermissionUntested PermissionResult = iota
+	PermissionAllowed
+	PermissionDenied
+	PermissionError
+	// PermissionAclsDisabled means the bucket enforces bucket owner ownership, so ACL
+	// calls are rejected no matter what the caller is allowed to do.
+	PermissionAclsDisabled
+)
+
+func (result PermissionResult) String() string {
+	switch result {
+	case PermissionAllowed:
+		return "allowed"
+	case PermissionDenied:
+		return "denied"
+	case PermissionError:
+		return "error"
+	case PermissionAclsDisabled:
+		return "ACLs disabled"
+	default:
+		return "untested"
+	}
+}
+
+// BucketPermissions is the permission matrix collected for a single bucket.
+// MarkerKey (and MarkerVersionIds on versioned buckets, which may include the delete marker)
+// is set when the probe marker object could not be cleaned up.
+type BucketPermissions struct {
+	Bucket              string
+	PutObject           PermissionResult
+	PutObjectAcl        PermissionResult
+	GetBucketPolicy     PermissionResult
+	PutBucketPolicy     PermissionResult
+	DeleteObject        PermissionResult
+	DeleteObjectVersion PermissionResult
+	MarkerKey           string
+	MarkerVersionIds    []string
+}
+
+// AccessPoint describes an S3 access point of any kind together with its policy.
+// Bucket and network origin are empty for Object Lambda and Multi-Region Access Points.
+type AccessPoint struct {
+	Kind          string
+	Name          string
+	Arn           string
+	Alias         string
+	Bucket        string
+	Region        string
+	NetworkOrigin string
+	VpcId         string
+	Policy        string
+}
+
+// ResourceSharing describes who besides the owner may use a snapshot or image. Unknown is
+// set when the permissions couldn't be read.
+type ResourceSharing struct {
+	Public        bool
+	Accounts      []string
+	Organizations []string
+	Unknown       error
+}
+
+// UnknownSharing is the sharing of a resource whose permissions couldn't be read.
+func UnknownSharing(err error) ResourceSharing {
+	return ResourceSharing{Unknown: err}
+}
+
+func (sharing ResourceSharing) IsShared() bool {
+	return sharing.Public || len(sharing.Accounts) > 0 || len(sharing.Organizations) > 0
+}
+
+// MayBeShared reports whether the resource is shared or might be, because its permissions
+// couldn't be read. Filters such as --shared-only keep those resources.
+func (sharing ResourceSharing) MayBeShared() bool {
+	return sharing.IsShared() || sharing.Unknown != nil
+}
+
+func (sharing ResourceSharing) String() string {
+	if sharing.Unknown != nil {
+		return fmt.Sprintf("sharing unknown (%v)", sharing.Unknown)
+	}
+
+	var parts []string
+	if sharing.Public {
+		parts = append(parts, "PUBLIC")
+	}
+	if len(sharing.Accounts) > 0 {
+		parts = append(parts, "accounts "+strings.Join(sharing.Accounts, ", "))
+	}
+	if len(sharing.Organizations) > 0 {
+		parts = append(parts, "organizations "+strings.Join(sharing.Organizations, ", "))
+	}
+	if len(parts) == 0 {
+		return "not shared"
+	}
+
+	return strings.Join(parts, "; ")
 }